@@ -0,0 +1,97 @@
+package iputil
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"net"
+)
+
+// Anonymizer maps addresses to anonymized addresses
+type Anonymizer interface {
+	// Anonymize returns the anonymized form of ip, or nil if ip is not a valid address.
+	// IPv4 addresses are returned in their 4 byte form.
+	Anonymize(ip net.IP) net.IP
+}
+
+// CryptoPAnKeyLen is the length of a CryptoPAn key
+const CryptoPAnKeyLen = 32
+
+// CryptoPAn is a prefix-preserving Anonymizer implementing Crypto-PAn (Xu, Fan, Ammar and Moon).
+// Any two addresses sharing a prefix of n bits are anonymized to addresses which share exactly
+// n bits, so subnet structure survives anonymization. The same key always gives the same mapping.
+type CryptoPAn struct {
+	block cipher.Block
+	pad   [aes.BlockSize]byte
+}
+
+// NewCryptoPAn returns a CryptoPAn using a 32 byte secret key. The first 16 bytes are the
+// AES key and the last 16 are encrypted to make the pad. ErrInvalidKey is returned if key
+// is the wrong length.
+func NewCryptoPAn(key []byte) (*CryptoPAn, error) {
+	if len(key) != CryptoPAnKeyLen {
+		return nil, ErrInvalidKey
+	}
+	block, err := aes.NewCipher(key[:16])
+	if err != nil {
+		return nil, err
+	}
+	c := &CryptoPAn{block: block}
+	block.Encrypt(c.pad[:], key[16:])
+	return c, nil
+}
+
+// Anonymize returns the prefix-preserving anonymized form of ip
+func (c *CryptoPAn) Anonymize(ip net.IP) net.IP {
+	orig := ip.To4()
+	if orig == nil {
+		if orig = ip.To16(); orig == nil {
+			return nil
+		}
+	}
+	var in, out [aes.BlockSize]byte
+	rip := make(net.IP, len(orig))
+	for pos := 0; pos < len(orig)*8; pos++ {
+		// the first pos bits of the address followed by the rest of the pad
+		in = c.pad
+		copy(in[:pos/8], orig[:pos/8])
+		if r := pos % 8; r > 0 {
+			m := byte(0xff) << (8 - r)
+			in[pos/8] = orig[pos/8]&m | c.pad[pos/8]&^m
+		}
+		c.block.Encrypt(out[:], in[:])
+		rip[pos/8] |= (out[0] >> 7) << (7 - pos%8)
+	}
+	for i := range rip {
+		rip[i] ^= orig[i]
+	}
+	return rip
+}
+
+// Truncate is an Anonymizer which zeroes the lowest bits of addresses, leaving the network
+// they are in. V4 and V6 are the number of bits zeroed in IPv4 and IPv6 addresses, so
+// Truncate{V4: 8, V6: 80} keeps the /24 or /48 containing an address.
+type Truncate struct {
+	V4, V6 int
+}
+
+// Anonymize returns ip with its lowest bits zeroed. The whole address is zeroed if
+// more bits are truncated than it has.
+func (t Truncate) Anonymize(ip net.IP) net.IP {
+	if ip4 := ip.To4(); ip4 != nil {
+		return TruncateIP(ip4, t.V4)
+	}
+	return TruncateIP(ip, t.V6)
+}
+
+// TruncateIP returns ip with its lowest n bits zeroed. IPv4 addresses are returned in
+// their 4 byte form. nil is returned if ip is not a valid address.
+func TruncateIP(ip net.IP, n int) net.IP {
+	bits := net.IPv6len * 8
+	if ip4 := ip.To4(); ip4 != nil {
+		ip, bits = ip4, net.IPv4len*8
+	} else if ip = ip.To16(); ip == nil {
+		return nil
+	}
+	ones := min(max(bits-n, 0), bits)
+	return FirstAddr(&net.IPNet{IP: ip, Mask: net.CIDRMask(ones, bits)})
+}
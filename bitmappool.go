@@ -0,0 +1,228 @@
+package iputil
+
+import (
+	"encoding/binary"
+	"math/bits"
+	"math/rand"
+	"net"
+	"sync"
+)
+
+// BitmapPool allocates addresses from an IPNet, tracking them in a bitmap of one bit per
+// address. It offers the same API as Pool in a fraction of the memory, which suits large
+// IPv4 pools; a /10 takes 512KiB. It is safe for concurrent use.
+type BitmapPool struct {
+	mu       sync.Mutex
+	first    net.IP
+	size     int
+	free     int
+	words    []uint64 // bit o%64 of word o/64 is set when offset o is allocated
+	exclude  ExcludeFunc
+	strategy Strategy
+}
+
+var _ AddressPool = (*BitmapPool)(nil)
+
+// NewBitmapPool returns a BitmapPool of the addresses in n, excluding the first xf and last xl
+// addresses. It returns the same errors as NewPool.
+func NewBitmapPool(n *net.IPNet, xf, xl int) (*BitmapPool, error) {
+	f, size, err := poolRange(n, xf, xl)
+	if err != nil {
+		return nil, err
+	}
+	p := &BitmapPool{first: f, size: size, free: size, words: make([]uint64, (size+63)/64)}
+	p.fillTail()
+	return p, nil
+}
+
+// fillTail marks the bits past the end of the pool in the last word as allocated, so scans skip them
+func (p *BitmapPool) fillTail() {
+	if r := p.size % 64; r != 0 {
+		p.words[len(p.words)-1] |= ^uint64(0) << r
+	}
+}
+
+// SetExclude sets a function called for each address before it is allocated by Allocate.
+// Excluded addresses are skipped, but remain free.
+func (p *BitmapPool) SetExclude(exclude ExcludeFunc) {
+	p.mu.Lock()
+	p.exclude = exclude
+	p.mu.Unlock()
+}
+
+// SetStrategy sets the Strategy used by Allocate to choose addresses. By default, or if s is nil,
+// Allocate scans the bitmap a word at a time for a free address, starting at a random offset.
+// A Strategy checks addresses one at a time, so is slower than the default on a large pool.
+func (p *BitmapPool) SetStrategy(s Strategy) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.strategy = s
+	p.notifyStrategy()
+}
+
+// notifyStrategy passes every allocated offset to the strategy's Allocated method
+func (p *BitmapPool) notifyStrategy() {
+	if p.strategy == nil {
+		return
+	}
+	for wi, w := range p.words {
+		for ; w != 0; w &= w - 1 {
+			if o := wi*64 + bits.TrailingZeros64(w); o < p.size {
+				p.strategy.Allocated(o)
+			}
+		}
+	}
+}
+
+// Size returns the number of addresses in the pool
+func (p *BitmapPool) Size() int {
+	return p.size
+}
+
+// Free returns the number of addresses which are not allocated
+func (p *BitmapPool) Free() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.free
+}
+
+// Allocate allocates a free address. ErrExhausted is returned if there are no free addresses.
+func (p *BitmapPool) Allocate() (net.IP, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.free == 0 {
+		return nil, ErrExhausted
+	}
+	var o int
+	var ok bool
+	if p.strategy != nil {
+		o, ok = p.strategy.Select(p.size, p.isFree)
+	} else {
+		o, ok = p.scan(rand.Intn(p.size))
+	}
+	if !ok {
+		return nil, ErrExhausted
+	}
+	p.set(o)
+	return IPAdd(p.first, o), nil
+}
+
+// scan returns the first free, unexcluded offset from start, wrapping around at the end of the pool
+func (p *BitmapPool) scan(start int) (int, bool) {
+	wi := start / 64
+	mask := ^uint64(0) << (start % 64)
+	// one more word than the bitmap, to check the bits before start in its word
+	for i := 0; i <= len(p.words); i++ {
+		for w := ^p.words[wi] & mask; w != 0; w &= w - 1 {
+			o := wi*64 + bits.TrailingZeros64(w)
+			if p.exclude == nil || !p.exclude(IPAdd(p.first, o)) {
+				return o, true
+			}
+		}
+		wi = (wi + 1) % len(p.words)
+		mask = ^uint64(0)
+	}
+	return 0, false
+}
+
+// isFree returns true if offset o is not allocated or excluded
+func (p *BitmapPool) isFree(o int) bool {
+	if p.isSet(o) {
+		return false
+	}
+	return p.exclude == nil || !p.exclude(IPAdd(p.first, o))
+}
+
+func (p *BitmapPool) isSet(o int) bool {
+	return p.words[o/64]&(1<<(o%64)) != 0
+}
+
+func (p *BitmapPool) set(o int) {
+	p.words[o/64] |= 1 << (o % 64)
+	p.free--
+	if p.strategy != nil {
+		p.strategy.Allocated(o)
+	}
+}
+
+// AllocateIP allocates a specific address. ErrNotInPool or ErrInUse are returned
+// if it is outside of the pool or already allocated.
+func (p *BitmapPool) AllocateIP(ip net.IP) error {
+	o, err := poolOffset(p.first, p.size, ip)
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.isSet(o) {
+		return ErrInUse
+	}
+	p.set(o)
+	return nil
+}
+
+// Release frees an allocated address. ErrNotInPool or ErrNotAllocated are returned
+// if it is outside of the pool or not allocated.
+func (p *BitmapPool) Release(ip net.IP) error {
+	o, err := poolOffset(p.first, p.size, ip)
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.isSet(o) {
+		return ErrNotAllocated
+	}
+	p.words[o/64] &^= 1 << (o % 64)
+	p.free++
+	if p.strategy != nil {
+		p.strategy.Released(o)
+	}
+	return nil
+}
+
+// Allocated returns true if ip is allocated
+func (p *BitmapPool) Allocated(ip net.IP) bool {
+	o, err := poolOffset(p.first, p.size, ip)
+	if err != nil {
+		return false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.isSet(o)
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler. The allocated addresses are encoded as the
+// pool size as a big endian uint64, followed by the bitmap as big endian uint64 words.
+func (p *BitmapPool) MarshalBinary() ([]byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	b := make([]byte, 0, 8+8*len(p.words))
+	b = binary.BigEndian.AppendUint64(b, uint64(p.size))
+	for _, w := range p.words {
+		b = binary.BigEndian.AppendUint64(b, w)
+	}
+	return b, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, restoring the allocated addresses
+// saved by MarshalBinary into a new pool created with the same IPNet and exclusions.
+// A Strategy can't be restored, so must be set afterwards. ErrPoolMismatch is returned
+// if the data is for a pool of a different size, or the pool already has a Strategy.
+func (p *BitmapPool) UnmarshalBinary(b []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.strategy != nil || len(b) != 8+8*len(p.words) || binary.BigEndian.Uint64(b) != uint64(p.size) {
+		return ErrPoolMismatch
+	}
+	free := len(p.words) * 64
+	for i := range p.words {
+		p.words[i] = binary.BigEndian.Uint64(b[8+8*i:])
+	}
+	p.fillTail()
+	for _, w := range p.words {
+		free -= bits.OnesCount64(w)
+	}
+	p.free = free
+	return nil
+}
@@ -0,0 +1,147 @@
+package iputil
+
+import (
+	"bytes"
+	"net"
+	"testing"
+)
+
+// nolint dupl
+func TestBitmapPoolAllocate(t *testing.T) {
+	_, sn, _ := net.ParseCIDR("10.1.0.0/25")
+	p, err := NewBitmapPool(sn, 1, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.Size() != 126 {
+		t.Errorf("expected 126 addresses, got %v", p.Size())
+	}
+	seen := map[string]bool{}
+	for i := 0; i < 126; i++ {
+		ip, err := p.Allocate()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if ip.Equal(net.ParseIP("10.1.0.0")) || ip.Equal(net.ParseIP("10.1.0.127")) || seen[ip.String()] {
+			t.Errorf("unexpected address %v", ip)
+		}
+		seen[ip.String()] = true
+	}
+	if _, err := p.Allocate(); err != ErrExhausted {
+		t.Errorf("Expected ErrExhausted, got %v", err)
+	}
+	if p.Free() != 0 {
+		t.Errorf("expected no free addresses, got %v", p.Free())
+	}
+}
+
+// nolint dupl
+func TestBitmapPoolAllocateIP(t *testing.T) {
+	_, sn, _ := net.ParseCIDR("10.1.0.0/29")
+	p, _ := NewBitmapPool(sn, 1, 1)
+	ip := net.ParseIP("10.1.0.3")
+	if err := p.AllocateIP(ip); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := p.AllocateIP(ip); err != ErrInUse {
+		t.Errorf("Expected ErrInUse, got %v", err)
+	}
+	for _, s := range []string{"10.1.0.0", "10.1.0.7", "10.1.1.1", "fe80::1"} {
+		if err := p.AllocateIP(net.ParseIP(s)); err != ErrNotInPool {
+			t.Errorf("%v: Expected ErrNotInPool, got %v", s, err)
+		}
+	}
+	if !p.Allocated(ip) || p.Free() != 5 {
+		t.Errorf("expected %v allocated and 5 free, got %v", ip, p.Free())
+	}
+	if err := p.Release(ip); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := p.Release(ip); err != ErrNotAllocated {
+		t.Errorf("Expected ErrNotAllocated, got %v", err)
+	}
+}
+
+// nolint dupl
+func TestBitmapPoolScan(t *testing.T) {
+	_, sn, _ := net.ParseCIDR("10.0.0.0/16")
+	p, _ := NewBitmapPool(sn, 0, 0)
+	for i := 0; i < p.Size(); i++ {
+		if i != 200 && i != 65000 {
+			_ = p.AllocateIP(IPAdd(sn.IP, i))
+		}
+	}
+	p.SetExclude(func(ip net.IP) bool { return ip.Equal(net.ParseIP("10.0.0.200")) })
+	ip, err := p.Allocate()
+	if err != nil || !ip.Equal(IPAdd(sn.IP, 65000)) {
+		t.Errorf("expected %v, got %v, %v", IPAdd(sn.IP, 65000), ip, err)
+	}
+	if _, err := p.Allocate(); err != ErrExhausted {
+		t.Errorf("Expected ErrExhausted, got %v", err)
+	}
+	if p.Free() != 1 {
+		t.Errorf("expected 1 free address, got %v", p.Free())
+	}
+}
+
+// nolint dupl
+func TestBitmapPoolStrategy(t *testing.T) {
+	_, sn, _ := net.ParseCIDR("10.1.0.0/29")
+	p, _ := NewBitmapPool(sn, 0, 0)
+	_ = p.AllocateIP(net.ParseIP("10.1.0.1"))
+	p.SetStrategy(SequentialStrategy())
+	for _, e := range []string{"10.1.0.0", "10.1.0.2"} {
+		if ip, _ := p.Allocate(); ip.String() != e {
+			t.Errorf("expected %v, got %v", e, ip)
+		}
+	}
+}
+
+// nolint dupl
+func TestBitmapPoolMarshal(t *testing.T) {
+	_, sn, _ := net.ParseCIDR("10.1.0.0/24")
+	p, _ := NewBitmapPool(sn, 1, 1)
+	var ips []net.IP
+	for i := 0; i < 10; i++ {
+		ip, _ := p.Allocate()
+		ips = append(ips, ip)
+	}
+	b, err := p.MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	p2, _ := NewBitmapPool(sn, 1, 1)
+	if err := p2.UnmarshalBinary(b); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, ip := range ips {
+		if !p2.Allocated(ip) {
+			t.Errorf("expected %v to be allocated", ip)
+		}
+	}
+	if p2.Free() != p.Free() {
+		t.Errorf("expected %v free, got %v", p.Free(), p2.Free())
+	}
+	p3, _ := NewBitmapPool(sn, 0, 0)
+	if err := p3.UnmarshalBinary(b); err != ErrPoolMismatch {
+		t.Errorf("Expected ErrPoolMismatch, got %v", err)
+	}
+	p4, _ := NewBitmapPool(sn, 1, 1)
+	p4.SetStrategy(MaxSpreadStrategy())
+	if err := p4.UnmarshalBinary(b); err != ErrPoolMismatch {
+		t.Errorf("Expected ErrPoolMismatch restoring into a pool with a strategy, got %v", err)
+	}
+}
+
+// nolint dupl
+func TestBitmapPoolMarshalFormat(t *testing.T) {
+	_, sn, _ := net.ParseCIDR("10.1.0.0/29")
+	p, _ := NewBitmapPool(sn, 0, 0)
+	_ = p.AllocateIP(net.ParseIP("10.1.0.0"))
+	_ = p.AllocateIP(net.ParseIP("10.1.0.7"))
+	b, _ := p.MarshalBinary()
+	e := []byte{0, 0, 0, 0, 0, 0, 0, 8, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x81}
+	if !bytes.Equal(b, e) {
+		t.Errorf("expected %x, got %x", e, b)
+	}
+}
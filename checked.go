@@ -0,0 +1,242 @@
+package iputil
+
+import (
+	"math/big"
+	"math/rand"
+	"net"
+)
+
+// checkNet returns an error if n is nil, has a non-canonical mask, or mixes
+// an IPv6 address with an IPv4 mask. Like the net package, an IPv4 address
+// with a 16 byte mask only uses the last 4 bytes of the mask.
+func checkNet(n *net.IPNet) error {
+	if n == nil {
+		return ErrInvalidMask
+	}
+	if len(n.IP) != net.IPv4len && len(n.IP) != net.IPv6len {
+		return ErrFamilyMismatch
+	}
+	m := n.Mask
+	switch len(m) {
+	case net.IPv4len:
+		if n.IP.To4() == nil {
+			return ErrFamilyMismatch
+		}
+	case net.IPv6len:
+		if len(n.IP) == net.IPv4len {
+			m = m[12:]
+		}
+	default:
+		return ErrInvalidMask
+	}
+	if _, bits := m.Size(); bits == 0 {
+		return ErrInvalidMask
+	}
+	return nil
+}
+
+// checkFamily returns ErrFamilyMismatch if one of ip and ip2 is IPv4 and the other is IPv6.
+// nil is the zero address of either family.
+func checkFamily(ip, ip2 net.IP) error {
+	if ip == nil || ip2 == nil {
+		return nil
+	}
+	if (ip.To4() == nil) != (ip2.To4() == nil) {
+		return ErrFamilyMismatch
+	}
+	return nil
+}
+
+// addrBits returns the size of the address space of ip, 32 for IPv4 and 128 for IPv6
+func addrBits(ip net.IP) uint {
+	if ip.To4() != nil {
+		return 32
+	}
+	return 128
+}
+
+func ipToInt(ip net.IP) *big.Int {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	return new(big.Int).SetBytes(ip)
+}
+
+// intToIP converts i to an IP of the same family and length as like.
+// i must fit in the address space of like.
+func intToIP(i *big.Int, like net.IP) net.IP {
+	rip := make(net.IP, len(like))
+	if len(like) == net.IPv6len && like.To4() != nil {
+		// keep IPv4 addresses in their 16 byte form
+		copy(rip, net.IPv4zero.To16())
+		i.FillBytes(rip[12:])
+		return rip
+	}
+	i.FillBytes(rip)
+	return rip
+}
+
+// FirstAddrChecked is FirstAddr, returning an error for an invalid IPNet
+func FirstAddrChecked(n *net.IPNet) (net.IP, error) {
+	if err := checkNet(n); err != nil {
+		return nil, err
+	}
+	return FirstAddr(n), nil
+}
+
+// LastAddrChecked is LastAddr, returning an error for an invalid IPNet
+func LastAddrChecked(n *net.IPNet) (net.IP, error) {
+	if err := checkNet(n); err != nil {
+		return nil, err
+	}
+	return LastAddr(n), nil
+}
+
+// NetworkIDChecked is NetworkID, returning an error for an invalid IPNet
+func NetworkIDChecked(n *net.IPNet) (*net.IPNet, error) {
+	if err := checkNet(n); err != nil {
+		return nil, err
+	}
+	return NetworkID(n), nil
+}
+
+// RandAddrChecked is RandAddr, returning an error for an invalid IPNet
+func RandAddrChecked(n *net.IPNet) (net.IP, error) {
+	if err := checkNet(n); err != nil {
+		return nil, err
+	}
+	return RandAddr(n), nil
+}
+
+// RandAddrWithExcludeChecked is RandAddrWithExclude, returning ErrExhausted if
+// the exclusions leave no addresses, and ErrOverflow for negative exclusions.
+// Unlike RandAddrWithExclude, the last address that is not excluded may be returned.
+func RandAddrWithExcludeChecked(n *net.IPNet, xf, xl int) (net.IP, error) {
+	if err := checkNet(n); err != nil {
+		return nil, err
+	}
+	if xf < 0 || xl < 0 {
+		return nil, ErrOverflow
+	}
+	f, err := IPAddChecked(FirstAddr(n), xf)
+	if err != nil {
+		return nil, ErrExhausted
+	}
+	l, err := IPAddChecked(LastAddr(n), -xl)
+	if err != nil {
+		return nil, ErrExhausted
+	}
+	if IPBefore(l, f) {
+		return nil, ErrExhausted
+	}
+	d, err := IPDiffChecked(l, f)
+	if err != nil || d == int(^uint(0)>>1) {
+		// The range is too large to count, the exclusions are a tiny part of it
+		for {
+			ip := RandAddr(n)
+			if !IPBefore(ip, f) && !IPBefore(l, ip) {
+				return ip, nil
+			}
+		}
+	}
+	return IPAdd(f, rand.Intn(d+1)), nil
+}
+
+// ExcludeFunc returns true if an address should not be chosen, for example because it is in use
+type ExcludeFunc func(ip net.IP) bool
+
+// maxExcludeTries is how many random addresses RandAddrWithExcludeFunc tries in a range too large to walk
+const maxExcludeTries = 1024
+
+// RandAddrWithExcludeFunc is RandAddrWithExcludeChecked, also skipping addresses for which exclude
+// returns true. If the random address is excluded, the following addresses are tried in order,
+// wrapping around the range, and ErrExhausted is returned if every address is excluded.
+func RandAddrWithExcludeFunc(n *net.IPNet, xf, xl int, exclude ExcludeFunc) (net.IP, error) {
+	ip, err := RandAddrWithExcludeChecked(n, xf, xl)
+	if err != nil || exclude == nil || !exclude(ip) {
+		return ip, err
+	}
+	f := IPAdd(FirstAddr(n), xf)
+	l := IPAdd(LastAddr(n), -xl)
+	d, err := IPDiffChecked(l, f)
+	if err != nil {
+		for i := 0; i < maxExcludeTries; i++ {
+			if ip, _ = RandAddrWithExcludeChecked(n, xf, xl); !exclude(ip) {
+				return ip, nil
+			}
+		}
+		return nil, ErrExhausted
+	}
+	for i := 0; i < d; i++ {
+		if ip.Equal(l) {
+			ip = f
+		} else {
+			ip = IPAdd(ip, 1)
+		}
+		if !exclude(ip) {
+			return ip, nil
+		}
+	}
+	return nil, ErrExhausted
+}
+
+// IPAddChecked is IPAdd, returning ErrOverflow instead of wrapping around the
+// address space. IPv4 addresses in 16 byte form are kept within the IPv4 space.
+func IPAddChecked(ip net.IP, offset int) (net.IP, error) {
+	return IPAddBig(ip, big.NewInt(int64(offset)))
+}
+
+// IPDiffChecked is IPDiff, returning ErrFamilyMismatch for addresses of different families
+// and ErrOverflow if the difference does not fit in an int
+func IPDiffChecked(ip, ip2 net.IP) (int, error) {
+	d, err := IPDiffBig(ip, ip2)
+	if err != nil {
+		return 0, err
+	}
+	if !d.IsInt64() || int64(int(d.Int64())) != d.Int64() {
+		return 0, ErrOverflow
+	}
+	return int(d.Int64()), nil
+}
+
+// IPBeforeChecked is IPBefore, returning ErrFamilyMismatch for addresses of different families
+func IPBeforeChecked(ip, ip2 net.IP) (bool, error) {
+	if err := checkFamily(ip, ip2); err != nil {
+		return false, err
+	}
+	return IPBefore(ip, ip2), nil
+}
+
+// checkNets validates non-nil IPNets and that they are of the same family
+func checkNets(net1, net2 *net.IPNet) error {
+	for _, n := range []*net.IPNet{net1, net2} {
+		if n == nil {
+			continue
+		}
+		if err := checkNet(n); err != nil {
+			return err
+		}
+	}
+	if net1 != nil && net2 != nil {
+		return checkFamily(net1.IP, net2.IP)
+	}
+	return nil
+}
+
+// SubnetEqualSubnetChecked is SubnetEqualSubnet, returning an error for invalid
+// IPNets or IPNets of different families
+func SubnetEqualSubnetChecked(net1, net2 *net.IPNet) (bool, error) {
+	if err := checkNets(net1, net2); err != nil {
+		return false, err
+	}
+	return SubnetEqualSubnet(net1, net2), nil
+}
+
+// SubnetContainsSubnetChecked is SubnetContainsSubnet, returning an error for invalid
+// IPNets or IPNets of different families
+func SubnetContainsSubnetChecked(supernet, subnet *net.IPNet) (bool, error) {
+	if err := checkNets(supernet, subnet); err != nil {
+		return false, err
+	}
+	return SubnetContainsSubnet(supernet, subnet), nil
+}
@@ -0,0 +1,173 @@
+package iputil
+
+import (
+	"net"
+	"testing"
+)
+
+// nolint dupl
+func TestFirstAddrCheckedLongMask(t *testing.T) {
+	net1 := &net.IPNet{
+		IP:   net.IP{10, 1, 6, 0},
+		Mask: net.IPMask{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 255, 255, 255, 0},
+	}
+	ip, err := FirstAddrChecked(net1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !ip.Equal(net.ParseIP("10.1.6.0")) {
+		t.Errorf("Expected %v to equal 10.1.6.0", ip)
+	}
+}
+
+// nolint dupl
+func TestFirstAddrCheckedFamilyMismatch(t *testing.T) {
+	net1 := &net.IPNet{IP: net.ParseIP("fe80::1"), Mask: net.CIDRMask(24, 32)}
+	if _, err := FirstAddrChecked(net1); err != ErrFamilyMismatch {
+		t.Errorf("Expected ErrFamilyMismatch, got %v", err)
+	}
+}
+
+// nolint dupl
+func TestLastAddrCheckedInvalidMask(t *testing.T) {
+	net1 := &net.IPNet{IP: net.IP{10, 1, 6, 0}, Mask: net.IPMask{255, 0, 255, 0}}
+	if _, err := LastAddrChecked(net1); err != ErrInvalidMask {
+		t.Errorf("Expected ErrInvalidMask, got %v", err)
+	}
+	if _, err := NetworkIDChecked(nil); err != ErrInvalidMask {
+		t.Errorf("Expected ErrInvalidMask for nil, got %v", err)
+	}
+}
+
+// nolint dupl
+func TestIPAddCheckedOverflow(t *testing.T) {
+	if _, err := IPAddChecked(net.ParseIP("255.255.255.255"), 1); err != ErrOverflow {
+		t.Errorf("Expected ErrOverflow, got %v", err)
+	}
+	if _, err := IPAddChecked(net.IP{0, 0, 0, 0}, -1); err != ErrOverflow {
+		t.Errorf("Expected ErrOverflow, got %v", err)
+	}
+	if _, err := IPAddChecked(net.ParseIP("ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff"), 1); err != ErrOverflow {
+		t.Errorf("Expected ErrOverflow, got %v", err)
+	}
+}
+
+// nolint dupl
+func TestIPAddChecked(t *testing.T) {
+	ip, err := IPAddChecked(net.ParseIP("10.1.255.255"), 5)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(ip) != net.IPv6len || !ip.Equal(net.ParseIP("10.2.0.4")) {
+		t.Errorf("%v should equal 10.2.0.4 in 16 byte form", ip)
+	}
+	ip, err = IPAddChecked(net.ParseIP("::5"), -5)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !ip.Equal(net.IPv6zero) || ip.To4() != nil {
+		t.Errorf("%v should equal ::", ip)
+	}
+}
+
+// nolint dupl
+func TestIPDiffChecked(t *testing.T) {
+	d, err := IPDiffChecked(net.ParseIP("10.1.1.0"), net.ParseIP("10.1.0.255"))
+	if err != nil || d != 1 {
+		t.Errorf("10.1.1.0 minus 10.1.0.255 should be 1, got %v, %v", d, err)
+	}
+	if _, err := IPDiffChecked(net.ParseIP("10.1.1.0"), net.ParseIP("fe80::1")); err != ErrFamilyMismatch {
+		t.Errorf("Expected ErrFamilyMismatch, got %v", err)
+	}
+	if _, err := IPDiffChecked(net.ParseIP("ffff::"), net.ParseIP("::")); err != ErrOverflow {
+		t.Errorf("Expected ErrOverflow, got %v", err)
+	}
+}
+
+// nolint dupl
+func TestIPBeforeCheckedFamilyMismatch(t *testing.T) {
+	if _, err := IPBeforeChecked(net.ParseIP("10.1.1.0"), net.ParseIP("fe80::1")); err != ErrFamilyMismatch {
+		t.Errorf("Expected ErrFamilyMismatch, got %v", err)
+	}
+	if b, err := IPBeforeChecked(nil, net.ParseIP("fe80::1")); err != nil || !b {
+		t.Errorf("nil should be before fe80::1, got %v, %v", b, err)
+	}
+}
+
+// nolint dupl
+func TestRandAddrWithExcludeCheckedExhausted(t *testing.T) {
+	_, sn, _ := net.ParseCIDR("10.1.0.0/24")
+	if _, err := RandAddrWithExcludeChecked(sn, 150, 150); err != ErrExhausted {
+		t.Errorf("Expected ErrExhausted, got %v", err)
+	}
+	if _, err := RandAddrWithExcludeChecked(sn, -1, 0); err != ErrOverflow {
+		t.Errorf("Expected ErrOverflow, got %v", err)
+	}
+}
+
+// nolint dupl
+func TestRandAddrWithExcludeCheckedSingle(t *testing.T) {
+	_, sn, _ := net.ParseCIDR("10.1.0.0/24")
+	ip, err := RandAddrWithExcludeChecked(sn, 100, 155)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !ip.Equal(net.ParseIP("10.1.0.100")) {
+		t.Errorf("%v should equal 10.1.0.100", ip)
+	}
+}
+
+// nolint dupl
+func TestRandAddrWithExcludeCheckedLarge6(t *testing.T) {
+	_, sn, _ := net.ParseCIDR("fe80::/64")
+	for i := 1; i <= 10; i++ {
+		ip, err := RandAddrWithExcludeChecked(sn, 1, 1)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !sn.Contains(ip) {
+			t.Errorf("IP %v outside subnet %v", ip, sn)
+		}
+	}
+}
+
+// nolint dupl
+func TestSubnetContainsSubnetChecked(t *testing.T) {
+	_, net1, _ := net.ParseCIDR("10.1.0.0/16")
+	_, net2, _ := net.ParseCIDR("fe80::/64")
+	if _, err := SubnetContainsSubnetChecked(net1, net2); err != ErrFamilyMismatch {
+		t.Errorf("Expected ErrFamilyMismatch, got %v", err)
+	}
+	if _, err := SubnetEqualSubnetChecked(net1, net2); err != ErrFamilyMismatch {
+		t.Errorf("Expected ErrFamilyMismatch, got %v", err)
+	}
+	if ok, err := SubnetContainsSubnetChecked(nil, net2); err != nil || !ok {
+		t.Errorf("nil should contain %v, got %v, %v", net2, ok, err)
+	}
+}
+
+// nolint dupl
+func TestRandAddrWithExcludeFunc(t *testing.T) {
+	_, sn, _ := net.ParseCIDR("10.1.0.0/29")
+	free := net.ParseIP("10.1.0.5")
+	ex := func(ip net.IP) bool { return !ip.Equal(free) }
+	for i := 0; i < 10; i++ {
+		ip, err := RandAddrWithExcludeFunc(sn, 1, 1, ex)
+		if err != nil || !ip.Equal(free) {
+			t.Errorf("expected %v, got %v, %v", free, ip, err)
+		}
+	}
+}
+
+// nolint dupl
+func TestRandAddrWithExcludeFuncExhausted(t *testing.T) {
+	_, sn, _ := net.ParseCIDR("10.1.0.0/29")
+	ex := func(ip net.IP) bool { return true }
+	if _, err := RandAddrWithExcludeFunc(sn, 0, 0, ex); err != ErrExhausted {
+		t.Errorf("Expected ErrExhausted, got %v", err)
+	}
+	_, sn6, _ := net.ParseCIDR("fe80::/64")
+	if _, err := RandAddrWithExcludeFunc(sn6, 0, 0, ex); err != ErrExhausted {
+		t.Errorf("Expected ErrExhausted, got %v", err)
+	}
+}
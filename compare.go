@@ -0,0 +1,102 @@
+package iputil
+
+import (
+	"bytes"
+	"net"
+	"slices"
+)
+
+// ipFamilyOrder returns the sort order of the family of ip, and ip in its canonical length
+func ipFamilyOrder(ip net.IP) (int, net.IP) {
+	if ip4 := ip.To4(); ip4 != nil {
+		return 1, ip4
+	}
+	if len(ip) == net.IPv6len {
+		return 2, ip
+	}
+	return 0, ip
+}
+
+// Compare returns -1 if ip < ip2, 0 if they are equal and 1 if ip > ip2. nil and invalid IPs
+// are first, followed by IPv4 addresses and then IPv6 addresses, each in address order.
+// IPv4 addresses compare equal in their 4 and 16 byte forms.
+// Unlike IPBefore, nil is not treated as the zero address.
+// Compare can be used with slices.SortFunc.
+func Compare(ip, ip2 net.IP) int {
+	f, a := ipFamilyOrder(ip)
+	f2, b := ipFamilyOrder(ip2)
+	if f != f2 {
+		return compareInt(f, f2)
+	}
+	return bytes.Compare(a, b)
+}
+
+// CompareNet returns -1 if a < b, 0 if they are equal and 1 if a > b. nil is first, then
+// IPNets are ordered by the family and address of their IP as in Compare, and then by prefix
+// length, shorter first. Host bits in the IP are not cleared, use NetworkID first to order by network.
+// CompareNet can be used with slices.SortFunc.
+func CompareNet(a, b *net.IPNet) int {
+	if a == nil || b == nil {
+		switch {
+		case a == b:
+			return 0
+		case a == nil:
+			return -1
+		}
+		return 1
+	}
+	if c := Compare(a.IP, b.IP); c != 0 {
+		return c
+	}
+	ma, mb := effectiveMask(a), effectiveMask(b)
+	ones, bits := ma.Size()
+	ones2, bits2 := mb.Size()
+	switch {
+	case bits != 0 && bits2 != 0:
+		return compareInt(ones, ones2)
+	case bits != bits2:
+		// canonical masks before non-canonical masks, which have no prefix length
+		return compareInt(bits2, bits)
+	}
+	return bytes.Compare(ma, mb)
+}
+
+// effectiveMask returns the mask of n, using the last 4 bytes of a 16 byte mask on an IPv4 address
+func effectiveMask(n *net.IPNet) net.IPMask {
+	if len(n.Mask) == net.IPv6len && n.IP.To4() != nil && len(n.IP) == net.IPv4len {
+		return n.Mask[12:]
+	}
+	return n.Mask
+}
+
+func compareInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// SortIPs sorts ips in place in the order of Compare
+func SortIPs(ips []net.IP) {
+	slices.SortFunc(ips, Compare)
+}
+
+// SortNets sorts nets in place in the order of CompareNet
+func SortNets(nets []*net.IPNet) {
+	slices.SortFunc(nets, CompareNet)
+}
+
+// DedupIPs sorts ips and removes duplicates, returning the shortened slice
+func DedupIPs(ips []net.IP) []net.IP {
+	SortIPs(ips)
+	return slices.CompactFunc(ips, func(a, b net.IP) bool { return Compare(a, b) == 0 })
+}
+
+// DedupNets sorts nets and removes duplicates, returning the shortened slice
+func DedupNets(nets []*net.IPNet) []*net.IPNet {
+	SortNets(nets)
+	return slices.CompactFunc(nets, func(a, b *net.IPNet) bool { return CompareNet(a, b) == 0 })
+}
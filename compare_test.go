@@ -0,0 +1,84 @@
+package iputil
+
+import (
+	"net"
+	"strings"
+	"testing"
+)
+
+func ipsString(ips []net.IP) string {
+	s := make([]string, len(ips))
+	for i, ip := range ips {
+		s[i] = ip.String()
+	}
+	return strings.Join(s, " ")
+}
+
+// nolint dupl
+func TestCompare(t *testing.T) {
+	for _, tc := range []struct {
+		a, b net.IP
+		e    int
+	}{
+		{net.ParseIP("10.1.0.1"), net.ParseIP("10.1.0.2"), -1},
+		{net.ParseIP("10.1.0.2"), net.ParseIP("10.1.0.1"), 1},
+		{net.ParseIP("10.1.0.1"), net.IP{10, 1, 0, 1}, 0},
+		{net.ParseIP("255.255.255.255"), net.ParseIP("::"), -1},
+		{nil, net.ParseIP("0.0.0.0"), -1},
+		{nil, nil, 0},
+		{net.ParseIP("fe80::2"), net.ParseIP("fe80::1"), 1},
+	} {
+		if c := Compare(tc.a, tc.b); c != tc.e {
+			t.Errorf("Compare(%v, %v) should be %v, got %v", tc.a, tc.b, tc.e, c)
+		}
+	}
+}
+
+// nolint dupl
+func TestCompareNet(t *testing.T) {
+	_, a, _ := net.ParseCIDR("10.1.0.0/16")
+	_, b, _ := net.ParseCIDR("10.1.0.0/24")
+	_, c, _ := net.ParseCIDR("10.0.0.0/24")
+	_, d, _ := net.ParseCIDR("::/0")
+	if CompareNet(a, b) != -1 || CompareNet(b, a) != 1 {
+		t.Errorf("%v should be before %v", a, b)
+	}
+	if CompareNet(c, a) != -1 || CompareNet(a, d) != -1 || CompareNet(nil, c) != -1 {
+		t.Errorf("unexpected order")
+	}
+	_, a2, _ := net.ParseCIDR("10.1.0.0/16")
+	if CompareNet(a, a2) != 0 || CompareNet(nil, nil) != 0 {
+		t.Errorf("%v should equal %v", a, a2)
+	}
+}
+
+// nolint dupl
+func TestSortIPs(t *testing.T) {
+	ips := []net.IP{net.ParseIP("fe80::1"), net.ParseIP("10.1.0.2"), nil, net.IP{10, 1, 0, 1}}
+	SortIPs(ips)
+	if r := ipsString(ips); r != "<nil> 10.1.0.1 10.1.0.2 fe80::1" {
+		t.Errorf("unexpected order %v", r)
+	}
+}
+
+// nolint dupl
+func TestDedupIPs(t *testing.T) {
+	ips := []net.IP{net.ParseIP("10.1.0.2"), net.IP{10, 1, 0, 2}, net.ParseIP("10.1.0.1"), net.ParseIP("10.1.0.2")}
+	ips = DedupIPs(ips)
+	if r := ipsString(ips); r != "10.1.0.1 10.1.0.2" {
+		t.Errorf("unexpected result %v", r)
+	}
+}
+
+// nolint dupl
+func TestSortDedupNets(t *testing.T) {
+	var nets []*net.IPNet
+	for _, s := range []string{"fe80::/64", "10.1.0.0/24", "10.1.0.0/16", "10.1.0.0/24", "10.0.0.0/8"} {
+		_, n, _ := net.ParseCIDR(s)
+		nets = append(nets, n)
+	}
+	nets = DedupNets(nets)
+	if r := netsString(nets); r != "10.0.0.0/8 10.1.0.0/16 10.1.0.0/24 fe80::/64" {
+		t.Errorf("unexpected result %v", r)
+	}
+}
@@ -0,0 +1,59 @@
+package iputil
+
+import (
+	"math/bits"
+	"net"
+)
+
+// CommonPrefixLen returns the number of leading bits shared by ip and ip2.
+// 0 is returned if they are not the same address family.
+func CommonPrefixLen(ip, ip2 net.IP) int {
+	if ip4, ip24 := ip.To4(), ip2.To4(); ip4 != nil && ip24 != nil {
+		ip, ip2 = ip4, ip24
+	} else if ip4 != nil || ip24 != nil {
+		return 0
+	}
+	return commonPrefixLen(ip, ip2)
+}
+
+// commonPrefixLen returns the number of leading bits shared by ip and ip2, compared
+// byte for byte as given
+func commonPrefixLen(ip, ip2 net.IP) int {
+	l := 0
+	for i := 0; i < len(ip) && i < len(ip2); i++ {
+		x := ip[i] ^ ip2[i]
+		l += bits.LeadingZeros8(x)
+		if x != 0 {
+			break
+		}
+	}
+	return l
+}
+
+// CoveringPrefix returns the smallest single subnet containing all of nets, so the covering
+// prefix of 10.1.4.0/24 and 10.1.7.0/24 is 10.1.4.0/22. Host addresses can be covered by
+// passing them as /32 or /128 subnets. ErrFamilyMismatch is returned if nets are not all
+// the same family. nil is returned if nets is empty.
+func CoveringPrefix(nets ...*net.IPNet) (*net.IPNet, error) {
+	var cover *net.IPNet
+	for _, n := range nets {
+		if err := checkNet(n); err != nil {
+			return nil, err
+		}
+		c := canonicalNet(n)
+		if cover == nil {
+			cover = c
+			continue
+		}
+		if len(c.IP) != len(cover.IP) {
+			return nil, ErrFamilyMismatch
+		}
+		ones, max := netSize(cover)
+		ones2, _ := netSize(c)
+		// canonical IPs are the same length as their masks, so IPv4-mapped prefixes
+		// with 16 byte masks are compared over all 128 bits
+		l := min(ones, ones2, commonPrefixLen(cover.IP, c.IP))
+		cover = NetworkID(&net.IPNet{IP: cover.IP, Mask: net.CIDRMask(l, max)})
+	}
+	return cover, nil
+}
@@ -0,0 +1,62 @@
+package iputil
+
+import (
+	"net"
+	"testing"
+)
+
+// nolint dupl
+func TestCommonPrefixLen(t *testing.T) {
+	for _, v := range []struct {
+		ip, ip2 string
+		e       int
+	}{
+		{"10.1.4.1", "10.1.7.1", 22},
+		{"10.1.4.1", "10.1.4.1", 32},
+		{"10.1.4.1", "::ffff:10.1.4.0", 31},
+		{"0.0.0.0", "128.0.0.0", 0},
+		{"2001:db8::1", "2001:db8::2", 126},
+		{"2001:db8::", "2001:db8::", 128},
+		{"10.1.4.1", "2001:db8::", 0},
+	} {
+		if l := CommonPrefixLen(net.ParseIP(v.ip), net.ParseIP(v.ip2)); l != v.e {
+			t.Errorf("%v, %v: expected %v, got %v", v.ip, v.ip2, v.e, l)
+		}
+	}
+}
+
+// nolint dupl
+func TestCoveringPrefix(t *testing.T) {
+	for _, v := range []struct {
+		nets []string
+		e    string
+	}{
+		{[]string{"10.1.4.0/24", "10.1.7.0/24"}, "10.1.4.0/22"},
+		{[]string{"10.1.4.0/24", "10.1.4.128/25"}, "10.1.4.0/24"},
+		{[]string{"10.1.4.128/25", "10.1.4.0/16"}, "10.1.0.0/16"},
+		{[]string{"10.1.4.5/32", "10.1.4.6/32"}, "10.1.4.4/30"},
+		{[]string{"10.0.0.0/8", "192.168.0.0/16"}, "0.0.0.0/0"},
+		{[]string{"2001:db8::/48", "2001:db8:ff::/48", "2001:db8:1::/64"}, "2001:db8::/40"},
+		{[]string{"2001:db8::/48"}, "2001:db8::/48"},
+		{[]string{"::ffff:10.0.0.0/120", "::ffff:10.0.1.0/120"}, "10.0.0.0/23"},
+	} {
+		var nets []*net.IPNet
+		for _, s := range v.nets {
+			nets = append(nets, mustCIDR(s))
+		}
+		n, err := CoveringPrefix(nets...)
+		if err != nil || n.String() != v.e {
+			t.Errorf("%v: expected %v, got %v, %v", v.nets, v.e, n, err)
+		}
+	}
+	n, _ := CoveringPrefix(mustCIDR("::ffff:10.0.0.0/120"), mustCIDR("::ffff:10.0.1.0/120"))
+	if ones, bits := n.Mask.Size(); ones != 119 || bits != 128 || !n.IP.Equal(net.ParseIP("10.0.0.0")) {
+		t.Errorf("expected ::ffff:10.0.0.0/119, got %v/%v", n.IP, ones)
+	}
+	if _, err := CoveringPrefix(mustCIDR("10.0.0.0/8"), mustCIDR("2001:db8::/32")); err != ErrFamilyMismatch {
+		t.Errorf("Expected ErrFamilyMismatch, got %v", err)
+	}
+	if n, err := CoveringPrefix(); n != nil || err != nil {
+		t.Errorf("expected nil, got %v, %v", n, err)
+	}
+}
@@ -0,0 +1,31 @@
+package iputil
+
+import "errors"
+
+var (
+	// ErrOverflow is returned when a result would wrap past the start or end of the address space,
+	// or not fit in the returned type
+	ErrOverflow = errors.New("iputil: address overflow")
+	// ErrFamilyMismatch is returned when IPv4 and IPv6 addresses or masks are mixed
+	ErrFamilyMismatch = errors.New("iputil: address family mismatch")
+	// ErrInvalidMask is returned for a nil or non-canonical mask
+	ErrInvalidMask = errors.New("iputil: invalid mask")
+	// ErrExhausted is returned when no addresses are left to choose from
+	ErrExhausted = errors.New("iputil: no addresses available")
+	// ErrHostBits is returned when a network address has bits set to the right of its mask
+	ErrHostBits = errors.New("iputil: host bits set in network address")
+	// ErrNoInterface is returned when no local interface has an address in a subnet containing an address
+	ErrNoInterface = errors.New("iputil: no local interface for address")
+	// ErrNotInPool is returned for an address outside of a pool
+	ErrNotInPool = errors.New("iputil: address not in pool")
+	// ErrInUse is returned when allocating an address which is already allocated
+	ErrInUse = errors.New("iputil: address in use")
+	// ErrNotAllocated is returned when releasing an address which is not allocated
+	ErrNotAllocated = errors.New("iputil: address not allocated")
+	// ErrPoolMismatch is returned when restoring saved pool state into a pool it does not match
+	ErrPoolMismatch = errors.New("iputil: pool state does not match pool")
+	// ErrNoLease is returned when renewing or releasing a lease which does not exist or has expired
+	ErrNoLease = errors.New("iputil: no such lease")
+	// ErrInvalidKey is returned for an anonymization key of the wrong length
+	ErrInvalidKey = errors.New("iputil: invalid key")
+)
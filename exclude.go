@@ -0,0 +1,57 @@
+package iputil
+
+import (
+	"net"
+)
+
+// Exclude returns the smallest list of subnets, in address order, covering the addresses of
+// supernet which are not in any of excluded. Like SubnetContainsSubnet, a nil excluded IPNet is the
+// global supernet. Excluded IPNets of the other address family are ignored. nil is returned
+// if supernet is invalid.
+func Exclude(supernet *net.IPNet, excluded ...*net.IPNet) []*net.IPNet {
+	n := canonicalNet(supernet)
+	if n == nil {
+		return nil
+	}
+	return exclude(n, excluded)
+}
+
+func exclude(n *net.IPNet, excluded []*net.IPNet) []*net.IPNet {
+	var inside []*net.IPNet
+	for _, e := range excluded {
+		if SubnetContainsSubnet(e, n) {
+			return nil
+		}
+		if SubnetContainsSubnet(n, e) {
+			inside = append(inside, e)
+		}
+	}
+	if len(inside) == 0 {
+		return []*net.IPNet{n}
+	}
+	lo, hi := splitNet(n)
+	return append(exclude(lo, inside), exclude(hi, inside)...)
+}
+
+// canonicalNet returns the network of n with an address and mask of the same length,
+// 4 bytes for IPv4 and 16 bytes for IPv6, or nil if n is invalid
+func canonicalNet(n *net.IPNet) *net.IPNet {
+	if checkNet(n) != nil {
+		return nil
+	}
+	ones, bits := netSize(n)
+	if bits == 32 {
+		return NetworkID(&net.IPNet{IP: n.IP.To4(), Mask: net.CIDRMask(ones, bits)})
+	}
+	return NetworkID(&net.IPNet{IP: n.IP.To16(), Mask: net.CIDRMask(ones, bits)})
+}
+
+// splitNet splits a canonical IPNet which is not a single address into its two halves
+func splitNet(n *net.IPNet) (*net.IPNet, *net.IPNet) {
+	ones, bits := n.Mask.Size()
+	m := net.CIDRMask(ones+1, bits)
+	hi := make(net.IP, len(n.IP))
+	copy(hi, n.IP)
+	hi[ones/8] |= 0x80 >> uint(ones%8)
+	return &net.IPNet{IP: n.IP, Mask: m}, &net.IPNet{IP: hi, Mask: m}
+}
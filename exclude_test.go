@@ -0,0 +1,69 @@
+package iputil
+
+import (
+	"net"
+	"strings"
+	"testing"
+)
+
+func netsString(nets []*net.IPNet) string {
+	s := make([]string, len(nets))
+	for i, n := range nets {
+		s[i] = n.String()
+	}
+	return strings.Join(s, " ")
+}
+
+// nolint dupl
+func TestExclude(t *testing.T) {
+	_, sn, _ := net.ParseCIDR("10.0.0.0/8")
+	_, e1, _ := net.ParseCIDR("10.1.2.0/24")
+	_, e2, _ := net.ParseCIDR("10.5.0.0/16")
+	r := netsString(Exclude(sn, e1, e2))
+	e := "10.0.0.0/16 10.1.0.0/23 10.1.3.0/24 10.1.4.0/22 10.1.8.0/21 10.1.16.0/20 10.1.32.0/19 " +
+		"10.1.64.0/18 10.1.128.0/17 10.2.0.0/15 10.4.0.0/16 10.6.0.0/15 10.8.0.0/13 10.16.0.0/12 " +
+		"10.32.0.0/11 10.64.0.0/10 10.128.0.0/9"
+	if r != e {
+		t.Errorf("expected %v, got %v", e, r)
+	}
+}
+
+// nolint dupl
+func TestExcludeNone(t *testing.T) {
+	sn, _ := CIDRToIPNet("10.1.0.5/16")
+	_, e1, _ := net.ParseCIDR("10.2.0.0/24")
+	if r := netsString(Exclude(sn, e1)); r != "10.1.0.0/16" {
+		t.Errorf("expected 10.1.0.0/16, got %v", r)
+	}
+}
+
+// nolint dupl
+func TestExcludeAll(t *testing.T) {
+	_, sn, _ := net.ParseCIDR("10.1.0.0/16")
+	_, e1, _ := net.ParseCIDR("10.0.0.0/8")
+	if r := Exclude(sn, e1); len(r) != 0 {
+		t.Errorf("expected nothing, got %v", netsString(r))
+	}
+	if r := Exclude(sn, nil); len(r) != 0 {
+		t.Errorf("expected nothing, got %v", netsString(r))
+	}
+}
+
+// nolint dupl
+func TestExcludeHost(t *testing.T) {
+	_, sn, _ := net.ParseCIDR("10.1.0.0/30")
+	e1, _ := CIDRToIPNet("10.1.0.2/32")
+	if r := netsString(Exclude(sn, e1)); r != "10.1.0.0/31 10.1.0.3/32" {
+		t.Errorf("expected 10.1.0.0/31 10.1.0.3/32, got %v", r)
+	}
+}
+
+// nolint dupl
+func TestExclude6(t *testing.T) {
+	_, sn, _ := net.ParseCIDR("2001:db8::/32")
+	_, e1, _ := net.ParseCIDR("2001:db8:8000::/34")
+	_, e2, _ := net.ParseCIDR("10.0.0.0/8")
+	if r := netsString(Exclude(sn, e1, e2)); r != "2001:db8::/33 2001:db8:c000::/34" {
+		t.Errorf("expected 2001:db8::/33 2001:db8:c000::/34, got %v", r)
+	}
+}
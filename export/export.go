@@ -0,0 +1,206 @@
+/*
+Package export writes lists of prefixes in the configuration formats of firewalls and routers.
+
+IPv4 and IPv6 prefixes are always written separately, as most formats do not allow a set
+or list to contain both families. Where the format has a single namespace for both
+families, the IPv4 list is named with a "_v4" suffix and the IPv6 list with a "_v6" suffix.
+Host bits are cleared from every prefix before it is written.
+*/
+package export
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strings"
+
+	"github.com/TrilliumIT/iputil"
+)
+
+// ErrInvalidName is returned when a set, list or chain name can not be written in a format
+var ErrInvalidName = errors.New("export: invalid name")
+
+var (
+	identRe   = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	nftRe     = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_.]*$`)
+	noSpaceRe = regexp.MustCompile(`^[!-~]+$`)
+)
+
+// ipsetMaxName is the maximum length of an ipset name
+const ipsetMaxName = 31
+
+// Nftables writes nftables set definitions with the interval flag, for use inside a table block
+func Nftables(w io.Writer, name string, nets []*net.IPNet) error {
+	if !nftRe.MatchString(name) {
+		return ErrInvalidName
+	}
+	return writeFamilies(w, nets, func(ew *errWriter, fam string, nets []*net.IPNet) {
+		typ := "ipv4_addr"
+		if fam == "v6" {
+			typ = "ipv6_addr"
+		}
+		ew.printf("set %v_%v {\n\ttype %v\n\tflags interval\n", name, fam, typ)
+		ew.printf("\telements = { %v }\n}\n", joinNets(nets, ", "))
+	})
+}
+
+// Ipset writes hash:net sets in the format read by ipset restore
+func Ipset(w io.Writer, name string, nets []*net.IPNet) error {
+	if !noSpaceRe.MatchString(name) || len(name)+3 > ipsetMaxName {
+		return ErrInvalidName
+	}
+	return writeFamilies(w, nets, func(ew *errWriter, fam string, nets []*net.IPNet) {
+		family := "inet"
+		if fam == "v6" {
+			family = "inet6"
+		}
+		ew.printf("create %v_%v hash:net family %v -exist\n", name, fam, family)
+		for _, n := range nets {
+			ew.printf("add %v_%v %v -exist\n", name, fam, n)
+		}
+	})
+}
+
+// Iptables writes iptables commands appending a rule to chain which jumps to target for each
+// IPv4 source prefix, and ip6tables commands for each IPv6 source prefix. The chain and target
+// are quoted for the shell.
+func Iptables(w io.Writer, chain, target string, nets []*net.IPNet) error {
+	if chain == "" || target == "" {
+		return ErrInvalidName
+	}
+	return writeFamilies(w, nets, func(ew *errWriter, fam string, nets []*net.IPNet) {
+		cmd := "iptables"
+		if fam == "v6" {
+			cmd = "ip6tables"
+		}
+		for _, n := range nets {
+			ew.printf("%v -A %v -s %v -j %v\n", cmd, shellQuote(chain), n, shellQuote(target))
+		}
+	})
+}
+
+// CiscoPrefixList writes Cisco IOS ip and ipv6 prefix-list entries permitting each prefix.
+// IOS keeps IPv4 and IPv6 prefix-lists separately, so both use name.
+func CiscoPrefixList(w io.Writer, name string, nets []*net.IPNet) error {
+	if !noSpaceRe.MatchString(name) {
+		return ErrInvalidName
+	}
+	return writeFamilies(w, nets, func(ew *errWriter, fam string, nets []*net.IPNet) {
+		cmd := "ip"
+		if fam == "v6" {
+			cmd = "ipv6"
+		}
+		for i, n := range nets {
+			ew.printf("%v prefix-list %v seq %v permit %v\n", cmd, name, (i+1)*5, n)
+		}
+	})
+}
+
+// JuniperPrefixList writes Junos set commands adding each prefix to a policy-options prefix-list.
+// The name is quoted if it contains characters other than letters, digits, '-' and '_'.
+func JuniperPrefixList(w io.Writer, name string, nets []*net.IPNet) error {
+	if name == "" || strings.ContainsAny(name, "\n\r") {
+		return ErrInvalidName
+	}
+	return writeFamilies(w, nets, func(ew *errWriter, fam string, nets []*net.IPNet) {
+		qn := junosQuote(name + "_" + fam)
+		for _, n := range nets {
+			ew.printf("set policy-options prefix-list %v %v\n", qn, n)
+		}
+	})
+}
+
+// BIRD writes BIRD filters which accept routes for the prefixes and reject all others
+func BIRD(w io.Writer, name string, nets []*net.IPNet) error {
+	if !identRe.MatchString(name) {
+		return ErrInvalidName
+	}
+	return writeFamilies(w, nets, func(ew *errWriter, fam string, nets []*net.IPNet) {
+		ew.printf("filter %v_%v {\n\tif net ~ [ %v ] then accept;\n\treject;\n}\n", name, fam, joinNets(nets, ", "))
+	})
+}
+
+// errWriter writes formatted output, keeping the first error
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (ew *errWriter) printf(format string, a ...interface{}) {
+	if ew.err != nil {
+		return
+	}
+	_, ew.err = fmt.Fprintf(ew.w, format, a...)
+}
+
+// writeFamilies normalizes nets and calls f for each family that has prefixes, IPv4 first
+func writeFamilies(w io.Writer, nets []*net.IPNet, f func(ew *errWriter, fam string, nets []*net.IPNet)) error {
+	var v4, v6 []*net.IPNet
+	for _, n := range nets {
+		nn, err := normalize(n)
+		if err != nil {
+			return err
+		}
+		if len(nn.IP) == net.IPv4len {
+			v4 = append(v4, nn)
+		} else {
+			v6 = append(v6, nn)
+		}
+	}
+	ew := &errWriter{w: w}
+	if len(v4) > 0 {
+		f(ew, "v4", v4)
+	}
+	if len(v6) > 0 {
+		f(ew, "v6", v6)
+	}
+	return ew.err
+}
+
+// normalize returns the network of n with a 4 byte address and mask for IPv4
+// and a 16 byte address and mask for IPv6
+func normalize(n *net.IPNet) (*net.IPNet, error) {
+	nid, err := iputil.NetworkIDChecked(n)
+	if err != nil {
+		return nil, err
+	}
+	m := nid.Mask
+	if len(nid.IP) == net.IPv4len && len(m) == net.IPv6len {
+		m = m[12:]
+	}
+	if len(m) == net.IPv4len {
+		return &net.IPNet{IP: nid.IP.To4(), Mask: m}, nil
+	}
+	return &net.IPNet{IP: nid.IP.To16(), Mask: m}, nil
+}
+
+func joinNets(nets []*net.IPNet, sep string) string {
+	s := make([]string, len(nets))
+	for i, n := range nets {
+		s[i] = n.String()
+	}
+	return strings.Join(s, sep)
+}
+
+var shellSafeRe = regexp.MustCompile(`^[A-Za-z0-9_.:/@%+=-]+$`)
+
+// shellQuote quotes s for a POSIX shell if it contains special characters
+func shellQuote(s string) string {
+	if shellSafeRe.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+var junosSafeRe = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// junosQuote quotes s for the Junos CLI if it contains special characters
+func junosQuote(s string) string {
+	if junosSafeRe.MatchString(s) {
+		return s
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	return `"` + r.Replace(s) + `"`
+}
@@ -0,0 +1,139 @@
+package export
+
+import (
+	"bytes"
+	"net"
+	"testing"
+
+	"github.com/TrilliumIT/iputil"
+)
+
+func testNets(t *testing.T) []*net.IPNet {
+	var nets []*net.IPNet
+	for _, s := range []string{"10.0.0.0/8", "2001:db8::1/32", "192.168.1.5/24"} {
+		n, err := iputil.CIDRToIPNet(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		nets = append(nets, n)
+	}
+	return nets
+}
+
+// nolint dupl
+func TestNftables(t *testing.T) {
+	var b bytes.Buffer
+	if err := Nftables(&b, "blocklist", testNets(t)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	e := `set blocklist_v4 {
+	type ipv4_addr
+	flags interval
+	elements = { 10.0.0.0/8, 192.168.1.0/24 }
+}
+set blocklist_v6 {
+	type ipv6_addr
+	flags interval
+	elements = { 2001:db8::/32 }
+}
+`
+	if b.String() != e {
+		t.Errorf("expected:\n%v\ngot:\n%v", e, b.String())
+	}
+	if err := Nftables(&b, "block list", nil); err != ErrInvalidName {
+		t.Errorf("Expected ErrInvalidName, got %v", err)
+	}
+}
+
+// nolint dupl
+func TestIpset(t *testing.T) {
+	var b bytes.Buffer
+	if err := Ipset(&b, "blocklist", testNets(t)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	e := `create blocklist_v4 hash:net family inet -exist
+add blocklist_v4 10.0.0.0/8 -exist
+add blocklist_v4 192.168.1.0/24 -exist
+create blocklist_v6 hash:net family inet6 -exist
+add blocklist_v6 2001:db8::/32 -exist
+`
+	if b.String() != e {
+		t.Errorf("expected:\n%v\ngot:\n%v", e, b.String())
+	}
+	if err := Ipset(&b, "a-name-that-is-far-too-long-for-ipset", nil); err != ErrInvalidName {
+		t.Errorf("Expected ErrInvalidName, got %v", err)
+	}
+}
+
+// nolint dupl
+func TestIptables(t *testing.T) {
+	var b bytes.Buffer
+	if err := Iptables(&b, "my chain", "DROP", testNets(t)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	e := `iptables -A 'my chain' -s 10.0.0.0/8 -j DROP
+iptables -A 'my chain' -s 192.168.1.0/24 -j DROP
+ip6tables -A 'my chain' -s 2001:db8::/32 -j DROP
+`
+	if b.String() != e {
+		t.Errorf("expected:\n%v\ngot:\n%v", e, b.String())
+	}
+}
+
+// nolint dupl
+func TestCiscoPrefixList(t *testing.T) {
+	var b bytes.Buffer
+	if err := CiscoPrefixList(&b, "BLOCK", testNets(t)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	e := `ip prefix-list BLOCK seq 5 permit 10.0.0.0/8
+ip prefix-list BLOCK seq 10 permit 192.168.1.0/24
+ipv6 prefix-list BLOCK seq 5 permit 2001:db8::/32
+`
+	if b.String() != e {
+		t.Errorf("expected:\n%v\ngot:\n%v", e, b.String())
+	}
+}
+
+// nolint dupl
+func TestJuniperPrefixList(t *testing.T) {
+	var b bytes.Buffer
+	if err := JuniperPrefixList(&b, `my "list"`, testNets(t)[:2]); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	e := `set policy-options prefix-list "my \"list\"_v4" 10.0.0.0/8
+set policy-options prefix-list "my \"list\"_v6" 2001:db8::/32
+`
+	if b.String() != e {
+		t.Errorf("expected:\n%v\ngot:\n%v", e, b.String())
+	}
+}
+
+// nolint dupl
+func TestBIRD(t *testing.T) {
+	var b bytes.Buffer
+	if err := BIRD(&b, "customers", testNets(t)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	e := `filter customers_v4 {
+	if net ~ [ 10.0.0.0/8, 192.168.1.0/24 ] then accept;
+	reject;
+}
+filter customers_v6 {
+	if net ~ [ 2001:db8::/32 ] then accept;
+	reject;
+}
+`
+	if b.String() != e {
+		t.Errorf("expected:\n%v\ngot:\n%v", e, b.String())
+	}
+}
+
+// nolint dupl
+func TestExportInvalidNet(t *testing.T) {
+	var b bytes.Buffer
+	n := &net.IPNet{IP: net.IP{10, 0, 0, 0}, Mask: net.IPMask{255, 0, 255, 0}}
+	if err := BIRD(&b, "customers", []*net.IPNet{n}); err != iputil.ErrInvalidMask {
+		t.Errorf("Expected ErrInvalidMask, got %v", err)
+	}
+}
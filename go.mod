@@ -0,0 +1,3 @@
+module github.com/TrilliumIT/iputil
+
+go 1.21
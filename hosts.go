@@ -0,0 +1,90 @@
+package iputil
+
+import (
+	"math/big"
+	"net"
+)
+
+// Reserve is a set of addresses in a subnet which are not usable by hosts
+type Reserve uint
+
+const (
+	// ReserveNetwork reserves the first address, the network address in IPv4
+	// and the subnet-router anycast address in IPv6
+	ReserveNetwork Reserve = 1 << iota
+	// ReserveBroadcast reserves the last address, the broadcast address. IPv6 has no broadcast
+	// address, so this is ignored for IPv6 subnets.
+	ReserveBroadcast
+	// ReserveGateway reserves one address for a gateway
+	ReserveGateway
+)
+
+// DefaultReserve reserves the network and broadcast addresses
+const DefaultReserve = ReserveNetwork | ReserveBroadcast
+
+// HostCount returns the number of addresses in n, or zero if n is invalid
+func HostCount(n *net.IPNet) *big.Int {
+	if checkNet(n) != nil {
+		return new(big.Int)
+	}
+	ones, bits := netSize(n)
+	return hostCount(ones, bits)
+}
+
+// UsableHostCount returns the number of addresses in n which are usable by hosts
+// after removing the addresses reserved by r.
+// Point to point subnets, /31 in IPv4 (RFC 3021) and /127 in IPv6 (RFC 6164), have no
+// network or broadcast address, and host routes, /32 and /128, have a single usable address.
+func UsableHostCount(n *net.IPNet, r Reserve) *big.Int {
+	if checkNet(n) != nil {
+		return new(big.Int)
+	}
+	ones, bits := netSize(n)
+	return usableHostCount(ones, bits, r)
+}
+
+// PrefixForHosts returns the longest prefix length in an address space of bits (32 or 128)
+// that has at least hosts usable addresses after removing the addresses reserved by r.
+// ErrOverflow is returned if the address space is not large enough.
+func PrefixForHosts(hosts *big.Int, bits int, r Reserve) (int, error) {
+	if bits != 32 && bits != 128 {
+		return 0, ErrInvalidMask
+	}
+	for ones := bits; ones >= 0; ones-- {
+		if usableHostCount(ones, bits, r).Cmp(hosts) >= 0 {
+			return ones, nil
+		}
+	}
+	return 0, ErrOverflow
+}
+
+// netSize returns the prefix length and address size of a valid IPNet, using the last
+// 4 bytes of a 16 byte mask on an IPv4 address.
+func netSize(n *net.IPNet) (ones, bits int) {
+	if len(n.IP) == net.IPv4len && len(n.Mask) == net.IPv6len {
+		return n.Mask[12:].Size()
+	}
+	return n.Mask.Size()
+}
+
+func hostCount(ones, bits int) *big.Int {
+	return new(big.Int).Lsh(big.NewInt(1), uint(bits-ones))
+}
+
+func usableHostCount(ones, bits int, r Reserve) *big.Int {
+	c := hostCount(ones, bits)
+	if ones == bits {
+		return c
+	}
+	var rc int64
+	if r&ReserveNetwork != 0 && ones < bits-1 {
+		rc++
+	}
+	if r&ReserveBroadcast != 0 && ones < bits-1 && bits == 32 {
+		rc++
+	}
+	if r&ReserveGateway != 0 {
+		rc++
+	}
+	return c.Sub(c, big.NewInt(rc))
+}
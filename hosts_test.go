@@ -0,0 +1,94 @@
+package iputil
+
+import (
+	"math/big"
+	"net"
+	"testing"
+)
+
+// nolint dupl
+func TestHostCount(t *testing.T) {
+	_, sn, _ := net.ParseCIDR("10.1.0.0/24")
+	if c := HostCount(sn); c.Int64() != 256 {
+		t.Errorf("%v should have 256 addresses, got %v", sn, c)
+	}
+}
+
+// nolint dupl
+func TestHostCount6(t *testing.T) {
+	_, sn, _ := net.ParseCIDR("fe80::/32")
+	e := new(big.Int).Lsh(big.NewInt(1), 96)
+	if c := HostCount(sn); c.Cmp(e) != 0 {
+		t.Errorf("%v should have %v addresses, got %v", sn, e, c)
+	}
+}
+
+// nolint dupl
+func TestHostCountInvalid(t *testing.T) {
+	sn := &net.IPNet{IP: net.IP{10, 1, 0, 0}, Mask: net.IPMask{255, 0, 255, 0}}
+	if c := HostCount(sn); c.Sign() != 0 {
+		t.Errorf("invalid IPNet should have 0 addresses, got %v", c)
+	}
+}
+
+// nolint dupl
+func TestUsableHostCount(t *testing.T) {
+	for _, tc := range []struct {
+		cidr string
+		r    Reserve
+		e    int64
+	}{
+		{"10.1.0.0/24", DefaultReserve, 254},
+		{"10.1.0.0/24", DefaultReserve | ReserveGateway, 253},
+		{"10.1.0.0/24", 0, 256},
+		{"10.1.0.0/30", DefaultReserve, 2},
+		{"10.1.0.0/31", DefaultReserve, 2},
+		{"10.1.0.0/31", DefaultReserve | ReserveGateway, 1},
+		{"10.1.0.0/32", DefaultReserve | ReserveGateway, 1},
+		{"fe80::/120", DefaultReserve, 255},
+		{"fe80::/127", DefaultReserve, 2},
+		{"fe80::/128", DefaultReserve, 1},
+	} {
+		_, sn, _ := net.ParseCIDR(tc.cidr)
+		if c := UsableHostCount(sn, tc.r); c.Int64() != tc.e {
+			t.Errorf("%v with reserve %v should have %v usable addresses, got %v", tc.cidr, tc.r, tc.e, c)
+		}
+	}
+}
+
+// nolint dupl
+func TestPrefixForHosts(t *testing.T) {
+	for _, tc := range []struct {
+		hosts int64
+		bits  int
+		r     Reserve
+		e     int
+	}{
+		{254, 32, DefaultReserve, 24},
+		{255, 32, DefaultReserve, 23},
+		{2, 32, DefaultReserve, 31},
+		{1, 32, DefaultReserve, 32},
+		{255, 128, DefaultReserve, 120},
+		{2, 128, DefaultReserve, 127},
+	} {
+		p, err := PrefixForHosts(big.NewInt(tc.hosts), tc.bits, tc.r)
+		if err != nil || p != tc.e {
+			t.Errorf("%v hosts should need a /%v, got %v, %v", tc.hosts, tc.e, p, err)
+		}
+	}
+}
+
+// nolint dupl
+func TestPrefixForHostsTooMany(t *testing.T) {
+	h := new(big.Int).Lsh(big.NewInt(1), 32)
+	if p, err := PrefixForHosts(h, 32, 0); err != nil || p != 0 {
+		t.Errorf("%v hosts should need a /0, got %v, %v", h, p, err)
+	}
+	h.Add(h, big.NewInt(1))
+	if _, err := PrefixForHosts(h, 32, 0); err != ErrOverflow {
+		t.Errorf("Expected ErrOverflow, got %v", err)
+	}
+	if _, err := PrefixForHosts(h, 33, 0); err != ErrInvalidMask {
+		t.Errorf("Expected ErrInvalidMask, got %v", err)
+	}
+}
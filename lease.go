@@ -0,0 +1,152 @@
+package iputil
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+)
+
+// Clock is the source of time for a LeasePool, so tests can control expiry
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// Lease is an address allocated to an ID until it expires
+type Lease struct {
+	ID      string
+	IP      net.IP
+	Expires time.Time
+}
+
+// LeasePool hands out addresses from an AddressPool as leases which expire unless they are renewed.
+// Expired leases are released by Reap, or by a reaper goroutine started with Run.
+type LeasePool struct {
+	mu     sync.Mutex
+	pool   AddressPool
+	clock  Clock
+	leases map[string]*Lease
+	events chan Lease
+	unsent []Lease // leases reaped by Run but not yet sent on events
+}
+
+// NewLeasePool returns a LeasePool allocating from pool. If clock is nil the system clock is used.
+func NewLeasePool(pool AddressPool, clock Clock) *LeasePool {
+	if clock == nil {
+		clock = realClock{}
+	}
+	return &LeasePool{pool: pool, clock: clock, leases: make(map[string]*Lease), events: make(chan Lease, 64)}
+}
+
+// Lease returns a lease for id which expires after ttl. If id already has a lease,
+// including an expired lease which has not been reaped, it is renewed and keeps its address.
+func (lp *LeasePool) Lease(id string, ttl time.Duration) (Lease, error) {
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+	if l, ok := lp.leases[id]; ok {
+		l.Expires = lp.clock.Now().Add(ttl)
+		return *l, nil
+	}
+	ip, err := lp.pool.Allocate()
+	if err != nil {
+		return Lease{}, err
+	}
+	l := &Lease{ID: id, IP: ip, Expires: lp.clock.Now().Add(ttl)}
+	lp.leases[id] = l
+	return *l, nil
+}
+
+// Renew extends the lease for id to expire after ttl. ErrNoLease is returned if id
+// has no lease or its lease has expired.
+func (lp *LeasePool) Renew(id string, ttl time.Duration) (Lease, error) {
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+	now := lp.clock.Now()
+	l, ok := lp.leases[id]
+	if !ok || !now.Before(l.Expires) {
+		return Lease{}, ErrNoLease
+	}
+	l.Expires = now.Add(ttl)
+	return *l, nil
+}
+
+// Release ends the lease for id and frees its address
+func (lp *LeasePool) Release(id string) error {
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+	l, ok := lp.leases[id]
+	if !ok {
+		return ErrNoLease
+	}
+	delete(lp.leases, id)
+	return lp.pool.Release(l.IP)
+}
+
+// Get returns the lease for id, if it has one which has not expired
+func (lp *LeasePool) Get(id string) (Lease, bool) {
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+	l, ok := lp.leases[id]
+	if !ok || !lp.clock.Now().Before(l.Expires) {
+		return Lease{}, false
+	}
+	return *l, true
+}
+
+// Reap releases every expired lease and returns them
+func (lp *LeasePool) Reap() []Lease {
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+	now := lp.clock.Now()
+	var expired []Lease
+	for id, l := range lp.leases {
+		if now.Before(l.Expires) {
+			continue
+		}
+		delete(lp.leases, id)
+		_ = lp.pool.Release(l.IP)
+		expired = append(expired, *l)
+	}
+	return expired
+}
+
+// Events returns the channel on which Run sends leases as they expire.
+// It must be read while Run is running, or reaping will stop.
+func (lp *LeasePool) Events() <-chan Lease {
+	return lp.events
+}
+
+// Run reaps expired leases every interval, sending each on the Events channel,
+// until ctx is done. Leases which were reaped but not yet sent when ctx is done
+// are kept, and sent first by the next call to Run.
+func (lp *LeasePool) Run(ctx context.Context, interval time.Duration) error {
+	lp.mu.Lock()
+	unsent := lp.unsent
+	lp.unsent = nil
+	lp.mu.Unlock()
+	for {
+		for len(unsent) > 0 {
+			select {
+			case lp.events <- unsent[0]:
+				unsent = unsent[1:]
+			case <-ctx.Done():
+				lp.mu.Lock()
+				lp.unsent = append(unsent, lp.unsent...)
+				lp.mu.Unlock()
+				return ctx.Err()
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-lp.clock.After(interval):
+		}
+		unsent = lp.Reap()
+	}
+}
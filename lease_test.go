@@ -0,0 +1,210 @@
+package iputil
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a Clock which only moves when advanced
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []fakeWaiter
+}
+
+type fakeWaiter struct {
+	t  time.Time
+	ch chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	c.waiters = append(c.waiters, fakeWaiter{t: c.now.Add(d), ch: ch})
+	return ch
+}
+
+// waiting returns the number of pending After calls
+func (c *fakeClock) waiting() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	var ws []fakeWaiter
+	for _, w := range c.waiters {
+		if !c.now.Before(w.t) {
+			w.ch <- c.now
+			continue
+		}
+		ws = append(ws, w)
+	}
+	c.waiters = ws
+}
+
+func testLeasePool(t *testing.T, cidr string) (*LeasePool, *fakeClock) {
+	_, sn, _ := net.ParseCIDR(cidr)
+	p, err := NewPool(sn, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := newFakeClock()
+	return NewLeasePool(p, c), c
+}
+
+// nolint dupl
+func TestLease(t *testing.T) {
+	lp, _ := testLeasePool(t, "10.1.0.0/29")
+	l, err := lp.Lease("a", time.Minute)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	l2, err := lp.Lease("a", time.Hour)
+	if err != nil || !l2.IP.Equal(l.IP) || !l2.Expires.After(l.Expires) {
+		t.Errorf("leasing the same id should renew, got %+v, %v", l2, err)
+	}
+	if g, ok := lp.Get("a"); !ok || !g.IP.Equal(l.IP) {
+		t.Errorf("expected lease %+v, got %+v", l, g)
+	}
+}
+
+// nolint dupl
+func TestLeaseExhausted(t *testing.T) {
+	lp, _ := testLeasePool(t, "10.1.0.0/30")
+	for _, id := range []string{"a", "b"} {
+		if _, err := lp.Lease(id, time.Minute); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if _, err := lp.Lease("c", time.Minute); err != ErrExhausted {
+		t.Errorf("Expected ErrExhausted, got %v", err)
+	}
+	if err := lp.Release("a"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := lp.Lease("c", time.Minute); err != nil {
+		t.Errorf("unexpected error after release: %v", err)
+	}
+	if err := lp.Release("a"); err != ErrNoLease {
+		t.Errorf("Expected ErrNoLease, got %v", err)
+	}
+}
+
+// nolint dupl
+func TestLeaseRenewExpired(t *testing.T) {
+	lp, c := testLeasePool(t, "10.1.0.0/29")
+	if _, err := lp.Lease("a", time.Minute); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c.Advance(30 * time.Second)
+	if _, err := lp.Renew("a", time.Minute); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	c.Advance(time.Minute)
+	if _, err := lp.Renew("a", time.Minute); err != ErrNoLease {
+		t.Errorf("Expected ErrNoLease, got %v", err)
+	}
+	if _, ok := lp.Get("a"); ok {
+		t.Errorf("expired lease should not be returned")
+	}
+}
+
+// nolint dupl
+func TestLeaseReap(t *testing.T) {
+	lp, c := testLeasePool(t, "10.1.0.0/29")
+	la, _ := lp.Lease("a", time.Minute)
+	_, _ = lp.Lease("b", time.Hour)
+	c.Advance(2 * time.Minute)
+	expired := lp.Reap()
+	if len(expired) != 1 || expired[0].ID != "a" {
+		t.Fatalf("expected lease a to expire, got %v", expired)
+	}
+	if lp.pool.Allocated(la.IP) {
+		t.Errorf("%v should have been released", la.IP)
+	}
+	if _, ok := lp.Get("b"); !ok {
+		t.Errorf("lease b should not have expired")
+	}
+}
+
+// nolint dupl
+func TestLeaseRun(t *testing.T) {
+	lp, c := testLeasePool(t, "10.1.0.0/29")
+	_, _ = lp.Lease("a", time.Minute)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- lp.Run(ctx, 10*time.Second) }()
+
+	for c.waiting() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	c.Advance(2 * time.Minute)
+	select {
+	case l := <-lp.Events():
+		if l.ID != "a" {
+			t.Errorf("expected lease a to expire, got %+v", l)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for expiry event")
+	}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+// nolint dupl
+func TestLeaseRunCancelKeepsUnsent(t *testing.T) {
+	lp, c := testLeasePool(t, "10.1.0.0/24")
+	n := cap(lp.events) + 6
+	for i := 0; i < n; i++ {
+		_, _ = lp.Lease(strconv.Itoa(i), time.Minute)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- lp.Run(ctx, 10*time.Second) }()
+	for c.waiting() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	c.Advance(2 * time.Minute)
+	for len(lp.events) < cap(lp.events) {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	seen := map[string]bool{}
+	for len(lp.events) > 0 {
+		seen[(<-lp.Events()).ID] = true
+	}
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	go func() { done <- lp.Run(ctx, 10*time.Second) }()
+	for len(seen) < n {
+		select {
+		case l := <-lp.Events():
+			seen[l.ID] = true
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out with %v of %v expiry events", len(seen), n)
+		}
+	}
+}
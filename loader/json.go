@@ -0,0 +1,141 @@
+package loader
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+)
+
+// prefixKeys are the JSON object keys, lower cased, whose values are loaded as prefixes
+var prefixKeys = map[string]bool{
+	"ip_prefix":       true, // AWS
+	"ipv6_prefix":     true, // AWS
+	"ipv4prefix":      true, // Google
+	"ipv6prefix":      true, // Google
+	"addressprefixes": true, // Azure
+	"prefix":          true,
+	"cidr":            true,
+}
+
+// LoadJSON loads prefixes from a JSON document, like the IP range feeds published by cloud
+// providers. Any object key named like ip_prefix, ipv6_prefix, ipv4Prefix, ipv6Prefix,
+// addressPrefixes, prefix or cidr holding a string or array of strings is loaded.
+// The other string, number and boolean values of the object, and of the objects containing it,
+// are added as tags, with the innermost value winning.
+func LoadJSON(r io.Reader, opts Options) (Collection, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	root, err := readJSON(dec)
+	if err != nil {
+		return nil, err
+	}
+	l := &loader{opts: opts}
+	l.walkJSON(b, root, nil)
+	return l.result()
+}
+
+// jsonValue is a decoded JSON value which remembers where its strings were
+type jsonValue struct {
+	scalar string // for strings, numbers and booleans
+	isStr  bool
+	offset int64 // offset of the end of a string in the input
+	keys   []string
+	vals   []*jsonValue // object values in the order of keys, or array elements
+	isObj  bool
+}
+
+func readJSON(dec *json.Decoder) (*jsonValue, error) {
+	t, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	return readJSONValue(dec, t)
+}
+
+func readJSONValue(dec *json.Decoder, t json.Token) (*jsonValue, error) {
+	switch t := t.(type) {
+	case json.Delim:
+		v := &jsonValue{isObj: t == '{'}
+		for dec.More() {
+			if v.isObj {
+				k, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				v.keys = append(v.keys, k.(string))
+			}
+			e, err := readJSON(dec)
+			if err != nil {
+				return nil, err
+			}
+			v.vals = append(v.vals, e)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return v, nil
+	case string:
+		return &jsonValue{scalar: t, isStr: true, offset: dec.InputOffset()}, nil
+	case json.Number:
+		return &jsonValue{scalar: t.String()}, nil
+	case bool:
+		if t {
+			return &jsonValue{scalar: "true"}, nil
+		}
+		return &jsonValue{scalar: "false"}, nil
+	case nil:
+		return &jsonValue{}, nil
+	}
+	return nil, errors.New("loader: unexpected json token")
+}
+
+// walkJSON loads prefixes from v and its children, with tags inherited from its parents
+func (l *loader) walkJSON(b []byte, v *jsonValue, tags map[string]string) {
+	if !v.isObj {
+		for _, e := range v.vals {
+			l.walkJSON(b, e, tags)
+		}
+		return
+	}
+
+	t := make(map[string]string, len(tags)+len(v.keys))
+	for k, tv := range tags {
+		t[k] = tv
+	}
+	for i, k := range v.keys {
+		if e := v.vals[i]; e.scalar != "" && !prefixKeys[strings.ToLower(k)] {
+			t[k] = e.scalar
+		}
+	}
+
+	for i, k := range v.keys {
+		e := v.vals[i]
+		if !prefixKeys[strings.ToLower(k)] {
+			l.walkJSON(b, e, t)
+			continue
+		}
+		ps := []*jsonValue{e}
+		if e.vals != nil && !e.isObj {
+			ps = e.vals
+		}
+		for _, p := range ps {
+			if p.isStr {
+				l.add(lineAt(b, p.offset), p.scalar, t)
+			}
+		}
+	}
+}
+
+// lineAt returns the line number of offset in b
+func lineAt(b []byte, offset int64) int {
+	if offset > int64(len(b)) {
+		offset = int64(len(b))
+	}
+	return bytes.Count(b[:offset], []byte("\n")) + 1
+}
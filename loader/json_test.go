@@ -0,0 +1,58 @@
+package loader
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// nolint dupl
+func TestLoadJSONAWS(t *testing.T) {
+	in := `{
+  "syncToken": "1700000000",
+  "prefixes": [
+    {"ip_prefix": "3.5.140.0/22", "region": "ap-northeast-2", "service": "AMAZON"},
+    {"ip_prefix": "bogus", "region": "us-east-1", "service": "EC2"}
+  ],
+  "ipv6_prefixes": [
+    {"ipv6_prefix": "2600:1f14::/35", "region": "us-west-2", "service": "EC2"}
+  ]
+}`
+	c, err := LoadJSON(strings.NewReader(in), Options{})
+	var errs Errors
+	if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Line != 5 {
+		t.Fatalf("expected an error on line 5, got %v", err)
+	}
+	if len(c) != 2 {
+		t.Fatalf("expected 2 entries, got %v", c)
+	}
+	if c[0].Net.String() != "3.5.140.0/22" || c[0].Tags["region"] != "ap-northeast-2" || c[0].Tags["syncToken"] != "1700000000" {
+		t.Errorf("unexpected entry %v", c[0])
+	}
+	if len(c.Filter("service", "EC2")) != 1 {
+		t.Errorf("expected 1 EC2 entry, got %v", c)
+	}
+}
+
+// nolint dupl
+func TestLoadJSONAzure(t *testing.T) {
+	in := `{"values": [{"name": "AzureCloud.eastus", "properties": {"region": "eastus",
+		"addressPrefixes": ["13.68.128.0/17", "2603:1030::/45"]}}]}`
+	c, err := LoadJSON(strings.NewReader(in), Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(c) != 2 || c[1].Net.String() != "2603:1030::/45" {
+		t.Fatalf("unexpected entries %v", c)
+	}
+	if c[0].Tags["name"] != "AzureCloud.eastus" || c[0].Tags["region"] != "eastus" {
+		t.Errorf("unexpected tags %v", c[0].Tags)
+	}
+}
+
+// nolint dupl
+func TestLoadJSONBad(t *testing.T) {
+	if _, err := LoadJSON(strings.NewReader(`{"prefixes": [`), Options{}); err == nil {
+		t.Errorf("loading invalid JSON should return an error")
+	}
+}
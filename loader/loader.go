@@ -0,0 +1,133 @@
+/*
+Package loader reads lists of prefixes from common file formats into tagged collections.
+
+Every loader returns the entries it was able to parse. Lines which could not be parsed are
+reported together in an Errors, so callers can choose to ignore bad lines.
+*/
+package loader
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/TrilliumIT/iputil"
+)
+
+// Entry is a prefix and the tags it was loaded with
+type Entry struct {
+	Net  *net.IPNet
+	Tags map[string]string
+}
+
+// Collection is a list of tagged prefixes
+type Collection []Entry
+
+// Nets returns the prefixes in c
+func (c Collection) Nets() []*net.IPNet {
+	nets := make([]*net.IPNet, len(c))
+	for i, e := range c {
+		nets[i] = e.Net
+	}
+	return nets
+}
+
+// Filter returns the entries in c with the tag key set to value
+func (c Collection) Filter(key, value string) Collection {
+	var r Collection
+	for _, e := range c {
+		if v, ok := e.Tags[key]; ok && v == value {
+			r = append(r, e)
+		}
+	}
+	return r
+}
+
+// Options control how prefixes are loaded
+type Options struct {
+	// Normalize clears host bits with NetworkID, otherwise they are preserved like CIDRToIPNet
+	Normalize bool
+	// Tags are added to every entry, tags from the input take precedence
+	Tags map[string]string
+	// Header makes LoadCSV read the first record as the names of the tag columns
+	Header bool
+}
+
+// LineError is an input line which could not be parsed
+type LineError struct {
+	Line int
+	Text string
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("line %v: %q: %v", e.Line, e.Text, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+// Errors is a list of LineErrors from a single load
+type Errors []*LineError
+
+func (e Errors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+	return fmt.Sprintf("%v (and %v more errors)", e[0].Error(), len(e)-1)
+}
+
+// loader accumulates entries and errors
+type loader struct {
+	opts Options
+	c    Collection
+	errs Errors
+}
+
+// add parses s as a prefix or a single address and adds it with tags
+func (l *loader) add(line int, s string, tags map[string]string) {
+	n, err := parsePrefix(s)
+	if err != nil {
+		l.fail(line, s, err)
+		return
+	}
+	if l.opts.Normalize {
+		n = iputil.NetworkID(n)
+	}
+	t := make(map[string]string, len(l.opts.Tags)+len(tags))
+	for k, v := range l.opts.Tags {
+		t[k] = v
+	}
+	for k, v := range tags {
+		t[k] = v
+	}
+	l.c = append(l.c, Entry{Net: n, Tags: t})
+}
+
+func (l *loader) fail(line int, text string, err error) {
+	l.errs = append(l.errs, &LineError{Line: line, Text: text, Err: err})
+}
+
+func (l *loader) result() (Collection, error) {
+	if len(l.errs) > 0 {
+		return l.c, l.errs
+	}
+	return l.c, nil
+}
+
+// parsePrefix parses a CIDR, or a single address as a host prefix
+func parsePrefix(s string) (*net.IPNet, error) {
+	if strings.Contains(s, "/") {
+		return iputil.CIDRToIPNet(s)
+	}
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, &net.ParseError{Type: "IP address", Text: s}
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}, nil
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+}
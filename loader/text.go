@@ -0,0 +1,113 @@
+package loader
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// LoadText loads one prefix or address per line. Blank lines are skipped, and text after
+// a '#' or ';' is a comment. A comment following a prefix is added as the "comment" tag,
+// as used by lists like Spamhaus DROP.
+func LoadText(r io.Reader, opts Options) (Collection, error) {
+	l := &loader{opts: opts}
+	s := bufio.NewScanner(r)
+	for line := 1; s.Scan(); line++ {
+		text := s.Text()
+		var tags map[string]string
+		if i := strings.IndexAny(text, "#;"); i >= 0 {
+			if c := strings.TrimSpace(text[i+1:]); c != "" {
+				tags = map[string]string{"comment": c}
+			}
+			text = text[:i]
+		}
+		text = strings.TrimSpace(text)
+		if text == "" {
+			continue
+		}
+		l.add(line, text, tags)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return l.result()
+}
+
+// LoadCSV loads a prefix or address from the first column of each record, with the remaining
+// columns as tags. With opts.Header, the first record is a header naming the tags, otherwise
+// tags are named by their column number, starting from 1. Lines starting with '#' are skipped.
+func LoadCSV(r io.Reader, opts Options) (Collection, error) {
+	l := &loader{opts: opts}
+	cr := csv.NewReader(r)
+	cr.Comment = '#'
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	var header []string
+	for needHeader := opts.Header; ; {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		var pe *csv.ParseError
+		if errors.As(err, &pe) {
+			l.fail(pe.Line, "", pe.Err)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := cr.FieldPos(0)
+		if needHeader {
+			header, needHeader = rec, false
+			continue
+		}
+		tags := make(map[string]string, len(rec)-1)
+		for i := 1; i < len(rec); i++ {
+			k := strconv.Itoa(i)
+			if i < len(header) && header[i] != "" {
+				k = header[i]
+			}
+			tags[k] = rec[i]
+		}
+		l.add(line, strings.TrimSpace(rec[0]), tags)
+	}
+	return l.result()
+}
+
+// LoadIpset loads the entries of sets in the output of ipset save, or an ipset restore file. Each entry is tagged with
+// the name of its set as "set". Entry options like timeouts are ignored, as are ports and
+// interfaces in sets like hash:net,port.
+func LoadIpset(r io.Reader, opts Options) (Collection, error) {
+	l := &loader{opts: opts}
+	s := bufio.NewScanner(r)
+	for line := 1; s.Scan(); line++ {
+		f := strings.Fields(s.Text())
+		if len(f) == 0 || strings.HasPrefix(f[0], "#") {
+			continue
+		}
+		switch f[0] {
+		case "add", "-A":
+		case "create", "-N", "flush", "-F", "destroy", "-X", "rename", "-E", "swap", "-W", "COMMIT":
+			continue
+		default:
+			l.fail(line, s.Text(), errors.New("unknown ipset command"))
+			continue
+		}
+		if len(f) < 3 {
+			l.fail(line, s.Text(), errors.New("missing ipset entry"))
+			continue
+		}
+		e := f[2]
+		if i := strings.IndexByte(e, ','); i >= 0 {
+			e = e[:i]
+		}
+		l.add(line, e, map[string]string{"set": f[1]})
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return l.result()
+}
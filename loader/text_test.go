@@ -0,0 +1,134 @@
+package loader
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+)
+
+// nolint dupl
+func TestLoadText(t *testing.T) {
+	in := `# Spamhaus style list
+1.10.16.0/20 ; SBL256894
+
+10.1.0.1/24
+2001:db8::1 # a host
+not a prefix
+`
+	c, err := LoadText(strings.NewReader(in), Options{Tags: map[string]string{"source": "test"}})
+	var errs Errors
+	if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Line != 6 {
+		t.Fatalf("expected an error on line 6, got %v", err)
+	}
+	if len(c) != 3 {
+		t.Fatalf("expected 3 entries, got %v", c)
+	}
+	if c[0].Net.String() != "1.10.16.0/20" || c[0].Tags["comment"] != "SBL256894" || c[0].Tags["source"] != "test" {
+		t.Errorf("unexpected entry %v", c[0])
+	}
+	if c[1].Net.String() != "10.1.0.1/24" {
+		t.Errorf("host bits of %v should be preserved", c[1].Net)
+	}
+	if c[2].Net.String() != "2001:db8::1/128" || c[2].Tags["comment"] != "a host" {
+		t.Errorf("unexpected entry %v", c[2])
+	}
+}
+
+// nolint dupl
+func TestLoadTextNormalize(t *testing.T) {
+	c, err := LoadText(strings.NewReader("10.1.0.1/24\n"), Options{Normalize: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c[0].Net.String() != "10.1.0.0/24" {
+		t.Errorf("%v should be normalized to 10.1.0.0/24", c[0].Net)
+	}
+}
+
+// nolint dupl
+func TestLoadCSVHeader(t *testing.T) {
+	in := `prefix,country,asn
+10.0.0.0/8,US,64500
+# comment
+bogus,CA,64501
+192.168.0.0/16, CA
+`
+	c, err := LoadCSV(strings.NewReader(in), Options{Header: true})
+	var errs Errors
+	if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Line != 4 {
+		t.Fatalf("expected an error on line 4, got %v", err)
+	}
+	if len(c) != 2 {
+		t.Fatalf("expected 2 entries, got %v", c)
+	}
+	if c[0].Tags["country"] != "US" || c[0].Tags["asn"] != "64500" {
+		t.Errorf("unexpected tags %v", c[0].Tags)
+	}
+	if c[1].Tags["country"] != "CA" || len(c.Filter("country", "CA")) != 1 {
+		t.Errorf("unexpected tags %v", c[1].Tags)
+	}
+}
+
+// nolint dupl
+func TestLoadCSVNoHeader(t *testing.T) {
+	c, err := LoadCSV(strings.NewReader("10.0.0.0/8,US\n2001:db8::/32,CA\n"), Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(c) != 2 || c[0].Tags["1"] != "US" || c[1].Net.String() != "2001:db8::/32" {
+		t.Errorf("unexpected entries %v", c)
+	}
+}
+
+// nolint dupl
+func TestLoadCSVBadFirstRow(t *testing.T) {
+	c, err := LoadCSV(strings.NewReader("bogus,eu\n10.0.0.0/8,us\n"), Options{})
+	var errs Errors
+	if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Line != 1 {
+		t.Fatalf("expected an error on line 1, got %v", err)
+	}
+	if len(c) != 1 || c[0].Tags["1"] != "us" {
+		t.Errorf("unexpected entries %v", c)
+	}
+}
+
+// nolint dupl
+func TestLoadCSVHeaderParseError(t *testing.T) {
+	in := "\"bad\"quote,x\nprefix,country\n10.0.0.0/8,US\n"
+	c, err := LoadCSV(strings.NewReader(in), Options{Header: true})
+	var errs Errors
+	if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Line != 1 {
+		t.Fatalf("expected an error on line 1, got %v", err)
+	}
+	if len(c) != 1 || c[0].Tags["country"] != "US" {
+		t.Errorf("unexpected entries %v", c)
+	}
+}
+
+// nolint dupl
+func TestLoadIpset(t *testing.T) {
+	in := `create blocklist hash:net family inet hashsize 1024 maxelem 65536
+add blocklist 10.0.0.0/8
+add blocklist 1.2.3.4 timeout 300
+create ports hash:net,port family inet6
+add ports 2001:db8::/32,tcp:80
+add ports
+bogus ports
+flush ports
+`
+	c, err := LoadIpset(strings.NewReader(in), Options{})
+	var errs Errors
+	if !errors.As(err, &errs) || len(errs) != 2 || errs[0].Line != 6 || errs[1].Line != 7 {
+		t.Fatalf("expected errors on lines 6 and 7, got %v", err)
+	}
+	if len(c) != 3 {
+		t.Fatalf("expected 3 entries, got %v", c)
+	}
+	if c[1].Net.String() != "1.2.3.4/32" || c[1].Tags["set"] != "blocklist" {
+		t.Errorf("unexpected entry %v", c[1])
+	}
+	if !c[2].Net.Contains(net.ParseIP("2001:db8::1")) || c[2].Tags["set"] != "ports" {
+		t.Errorf("unexpected entry %v", c[2])
+	}
+}
@@ -0,0 +1,209 @@
+package iputil
+
+import (
+	"net"
+)
+
+// LocalAddr is an address of a local interface. Like CIDRToIPNet, the host bits
+// of the IPNet are preserved, they are the address of the interface.
+type LocalAddr struct {
+	*net.IPNet
+	Interface *net.Interface
+}
+
+// LocalAddrs returns the addresses of all local interfaces
+func LocalAddrs() ([]LocalAddr, error) {
+	ifs, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	var las []LocalAddr
+	for i := range ifs {
+		addrs, err := ifs[i].Addrs()
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range addrs {
+			if n, ok := a.(*net.IPNet); ok {
+				las = append(las, LocalAddr{IPNet: n, Interface: &ifs[i]})
+			}
+		}
+	}
+	return las, nil
+}
+
+// InterfaceForIP returns the local address with the most specific subnet containing ip,
+// and so the interface which reaches ip directly. ErrNoInterface is returned if ip is not on
+// a local subnet.
+func InterfaceForIP(ip net.IP) (LocalAddr, error) {
+	las, err := LocalAddrs()
+	if err != nil {
+		return LocalAddr{}, err
+	}
+	if la, ok := interfaceForIP(ip, las); ok {
+		return la, nil
+	}
+	return LocalAddr{}, ErrNoInterface
+}
+
+func interfaceForIP(ip net.IP, las []LocalAddr) (LocalAddr, bool) {
+	var best LocalAddr
+	bestOnes := -1
+	for _, la := range las {
+		if la.IPNet == nil || !la.Contains(ip) {
+			continue
+		}
+		if ones, _ := la.Mask.Size(); ones > bestOnes {
+			best, bestOnes = la, ones
+		}
+	}
+	return best, bestOnes >= 0
+}
+
+// SourceAddr returns the local address that should be used as the source for
+// connections to dst, following the source address selection rules of RFC 6724
+func SourceAddr(dst net.IP) (LocalAddr, error) {
+	las, err := LocalAddrs()
+	if err != nil {
+		return LocalAddr{}, err
+	}
+	if la, ok := SelectSourceAddr(dst, las); ok {
+		return la, nil
+	}
+	return LocalAddr{}, ErrNoInterface
+}
+
+// SelectSourceAddr chooses the source address for dst from candidates, following
+// the source address selection rules of RFC 6724 section 5. Only candidates of the same
+// family as dst are considered. Rules 3, 4 and 7 need information which is not available
+// from the net package, so are skipped.
+func SelectSourceAddr(dst net.IP, candidates []LocalAddr) (LocalAddr, bool) {
+	out, _ := interfaceForIP(dst, candidates)
+	var best LocalAddr
+	found := false
+	for _, c := range candidates {
+		if c.IPNet == nil || checkFamily(c.IP, dst) != nil {
+			continue
+		}
+		if !found || preferSource(dst, c, best, out.Interface) {
+			best, found = c, true
+		}
+	}
+	return best, found
+}
+
+// preferSource returns true if sa is a better source for dst than sb, by RFC 6724 section 5
+func preferSource(dst net.IP, sa, sb LocalAddr, out *net.Interface) bool {
+	// Rule 1: prefer same address
+	if sa.IP.Equal(dst) != sb.IP.Equal(dst) {
+		return sa.IP.Equal(dst)
+	}
+	// Rule 2: prefer appropriate scope
+	scA, scB, scD := addrScope(sa.IP), addrScope(sb.IP), addrScope(dst)
+	if scA < scB {
+		return scA >= scD
+	}
+	if scB < scA {
+		return scB < scD
+	}
+	// Rule 5: prefer outgoing interface
+	if out != nil && sa.Interface != nil && sb.Interface != nil {
+		ia, ib := sa.Interface.Index == out.Index, sb.Interface.Index == out.Index
+		if ia != ib {
+			return ia
+		}
+	}
+	// Rule 6: prefer matching label
+	ld := policyLabel(dst)
+	if la, lb := policyLabel(sa.IP) == ld, policyLabel(sb.IP) == ld; la != lb {
+		return la
+	}
+	// Rule 8: use longest matching prefix
+	return sourcePrefixLen(sa, dst) > sourcePrefixLen(sb, dst)
+}
+
+// address scopes from RFC 4291 and RFC 6724 section 3.1
+const (
+	scopeLinkLocal = 0x2
+	scopeSiteLocal = 0x5
+	scopeGlobal    = 0xe
+)
+
+// addrScope returns the scope of ip by RFC 6724 section 3.1 and 3.2
+func addrScope(ip net.IP) int {
+	if ip4 := ip.To4(); ip4 != nil {
+		if ip4[0] == 127 || (ip4[0] == 169 && ip4[1] == 254) {
+			return scopeLinkLocal
+		}
+		return scopeGlobal
+	}
+	switch {
+	case ip.IsMulticast():
+		return int(ip[1] & 0xf)
+	case ip.IsLoopback(), ip.IsLinkLocalUnicast():
+		return scopeLinkLocal
+	case ip[0] == 0xfe && ip[1]&0xc0 == 0xc0:
+		return scopeSiteLocal
+	}
+	return scopeGlobal
+}
+
+// policyTable is the default policy table of RFC 6724 section 2.1, most specific first
+var policyTable = func() []struct {
+	n     *net.IPNet
+	label int
+} {
+	entries := []struct {
+		cidr  string
+		label int
+	}{
+		{"::1/128", 0},
+		{"::ffff:0:0/96", 4},
+		{"::/96", 3},
+		{"2001::/32", 5},
+		{"2002::/16", 2},
+		{"3ffe::/16", 12},
+		{"fec0::/10", 11},
+		{"fc00::/7", 13},
+		{"::/0", 1},
+	}
+	t := make([]struct {
+		n     *net.IPNet
+		label int
+	}, len(entries))
+	for i, e := range entries {
+		_, t[i].n, _ = net.ParseCIDR(e.cidr)
+		t[i].label = e.label
+	}
+	return t
+}()
+
+// policyLabel returns the label of ip in the default policy table. IPv4 addresses
+// are looked up as IPv4-mapped IPv6 addresses.
+func policyLabel(ip net.IP) int {
+	ip16 := ip.To16()
+	for _, e := range policyTable {
+		// compare all 16 bytes, net.IPNet.Contains treats ::ffff:0:0/96 as an IPv4 network
+		match := true
+		for i := range ip16 {
+			if ip16[i]&e.n.Mask[i] != e.n.IP[i] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return e.label
+		}
+	}
+	return 1
+}
+
+// sourcePrefixLen returns the number of leading bits shared by the source and dst,
+// up to the prefix length of the source's subnet, by RFC 6724 section 2.2
+func sourcePrefixLen(s LocalAddr, dst net.IP) int {
+	l := CommonPrefixLen(s.IP, dst)
+	if ones, _ := s.Mask.Size(); l > ones {
+		return ones
+	}
+	return l
+}
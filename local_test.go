@@ -0,0 +1,138 @@
+package iputil
+
+import (
+	"net"
+	"testing"
+)
+
+func testLocalAddr(t *testing.T, cidr string, index int) LocalAddr {
+	n, err := CIDRToIPNet(cidr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return LocalAddr{IPNet: n, Interface: &net.Interface{Index: index}}
+}
+
+// nolint dupl
+func TestLocalAddrs(t *testing.T) {
+	las, err := LocalAddrs()
+	if err != nil {
+		t.Skipf("no interfaces: %v", err)
+	}
+	for _, la := range las {
+		if la.Interface == nil || !la.Contains(la.IP) {
+			t.Errorf("local address %v should have an interface and contain its address", la.IPNet)
+		}
+	}
+}
+
+// nolint dupl
+func TestInterfaceForIPLoopback(t *testing.T) {
+	la, err := InterfaceForIP(net.ParseIP("127.0.0.1"))
+	if err != nil {
+		t.Skipf("no loopback interface: %v", err)
+	}
+	if la.Interface.Flags&net.FlagLoopback == 0 {
+		t.Errorf("127.0.0.1 should be reached by a loopback interface, got %v", la.Interface.Name)
+	}
+}
+
+// nolint dupl
+func TestInterfaceForIPMostSpecific(t *testing.T) {
+	las := []LocalAddr{
+		testLocalAddr(t, "10.0.0.5/8", 1),
+		testLocalAddr(t, "10.1.0.5/16", 2),
+		testLocalAddr(t, "fe80::1/64", 3),
+	}
+	la, ok := interfaceForIP(net.ParseIP("10.1.2.3"), las)
+	if !ok || la.Interface.Index != 2 {
+		t.Errorf("10.1.2.3 should be reached by interface 2, got %v", la.Interface)
+	}
+	if _, ok := interfaceForIP(net.ParseIP("192.168.0.1"), las); ok {
+		t.Errorf("192.168.0.1 should not be reached by any interface")
+	}
+}
+
+// nolint dupl
+func TestSelectSourceAddrScope(t *testing.T) {
+	las := []LocalAddr{
+		testLocalAddr(t, "fe80::1/64", 1),
+		testLocalAddr(t, "2001:db8::1/64", 1),
+	}
+	la, _ := SelectSourceAddr(net.ParseIP("2001:db9::1"), las)
+	if !la.IP.Equal(net.ParseIP("2001:db8::1")) {
+		t.Errorf("global destination should use a global source, got %v", la.IP)
+	}
+	la, _ = SelectSourceAddr(net.ParseIP("fe80::2"), las)
+	if !la.IP.Equal(net.ParseIP("fe80::1")) {
+		t.Errorf("link-local destination should use a link-local source, got %v", la.IP)
+	}
+}
+
+// nolint dupl
+func TestSelectSourceAddrSame(t *testing.T) {
+	las := []LocalAddr{
+		testLocalAddr(t, "10.0.0.5/8", 1),
+		testLocalAddr(t, "10.0.0.6/8", 1),
+	}
+	la, _ := SelectSourceAddr(net.ParseIP("10.0.0.6"), las)
+	if !la.IP.Equal(net.ParseIP("10.0.0.6")) {
+		t.Errorf("destination address should be its own source, got %v", la.IP)
+	}
+}
+
+// nolint dupl
+func TestSelectSourceAddrOutgoing(t *testing.T) {
+	las := []LocalAddr{
+		testLocalAddr(t, "10.2.0.5/16", 1),
+		testLocalAddr(t, "192.168.0.5/24", 2),
+	}
+	la, _ := SelectSourceAddr(net.ParseIP("192.168.0.9"), las)
+	if la.Interface.Index != 2 {
+		t.Errorf("source should be on the outgoing interface, got %v", la.IP)
+	}
+}
+
+// nolint dupl
+func TestSelectSourceAddrLabel(t *testing.T) {
+	las := []LocalAddr{
+		testLocalAddr(t, "2002:c000:204::1/48", 1),
+		testLocalAddr(t, "2001:db8::1/64", 1),
+	}
+	la, _ := SelectSourceAddr(net.ParseIP("2002:c633:6401::1"), las)
+	if !la.IP.Equal(net.ParseIP("2002:c000:204::1")) {
+		t.Errorf("6to4 destination should use a 6to4 source, got %v", la.IP)
+	}
+}
+
+// nolint dupl
+func TestSelectSourceAddrLongestPrefix(t *testing.T) {
+	las := []LocalAddr{
+		testLocalAddr(t, "2001:db8:1::1/64", 1),
+		testLocalAddr(t, "2001:db8:3::1/64", 1),
+	}
+	la, _ := SelectSourceAddr(net.ParseIP("2001:db8:3:5::1"), las)
+	if !la.IP.Equal(net.ParseIP("2001:db8:3::1")) {
+		t.Errorf("source with the longest matching prefix should be used, got %v", la.IP)
+	}
+}
+
+// nolint dupl
+func TestSelectSourceAddrFamily(t *testing.T) {
+	las := []LocalAddr{testLocalAddr(t, "2001:db8::1/64", 1)}
+	if _, ok := SelectSourceAddr(net.ParseIP("10.0.0.1"), las); ok {
+		t.Errorf("IPv6 source should not be selected for IPv4 destination")
+	}
+}
+
+// nolint dupl
+func TestSelectSourceAddrNil(t *testing.T) {
+	las := []LocalAddr{
+		{},
+		testLocalAddr(t, "10.2.0.5/16", 1),
+	}
+	la, ok := SelectSourceAddr(net.ParseIP("10.2.0.9"), las)
+	if !ok || !la.IP.Equal(net.ParseIP("10.2.0.5")) {
+		t.Errorf("expected 10.2.0.5, got %v, %v", la.IPNet, ok)
+	}
+}
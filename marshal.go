@@ -0,0 +1,225 @@
+package iputil
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"strings"
+)
+
+// Prefix is an IPNet which marshals to and from CIDR notation.
+// Like CIDRToIPNet, host bits are preserved so "10.1.0.1/24" round-trips exactly.
+// Prefix implements encoding.TextMarshaler, so it can also be used with YAML and TOML
+// packages which support it.
+type Prefix struct {
+	net.IPNet
+}
+
+// String returns the CIDR notation of p, or "" for the zero Prefix
+func (p Prefix) String() string {
+	if p.IP == nil {
+		return ""
+	}
+	return p.IPNet.String()
+}
+
+// Set parses s into p, implementing flag.Value
+func (p *Prefix) Set(s string) error {
+	return p.UnmarshalText([]byte(s))
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (p Prefix) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. Empty text is the zero Prefix.
+func (p *Prefix) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*p = Prefix{}
+		return nil
+	}
+	n, err := CIDRToIPNet(string(text))
+	if err != nil {
+		return err
+	}
+	p.IPNet = *n
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler
+func (p Prefix) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler. null is the zero Prefix.
+func (p *Prefix) UnmarshalJSON(b []byte) error {
+	return unmarshalJSONText(b, p.UnmarshalText)
+}
+
+// Range is an inclusive range of addresses, formatted as "10.1.0.10-10.1.0.20"
+type Range struct {
+	First net.IP
+	Last  net.IP
+}
+
+// Contains returns true if ip is in r
+func (r Range) Contains(ip net.IP) bool {
+	if r.First == nil || ip == nil || checkFamily(r.First, ip) != nil {
+		return false
+	}
+	return !IPBefore(ip, r.First) && !IPBefore(r.Last, ip)
+}
+
+// String returns r as "first-last", or "" for the zero Range
+func (r Range) String() string {
+	if r.First == nil {
+		return ""
+	}
+	return r.First.String() + "-" + r.Last.String()
+}
+
+// Set parses s into r, implementing flag.Value
+func (r *Range) Set(s string) error {
+	return r.UnmarshalText([]byte(s))
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (r Range) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. Text is either "first-last" or
+// a CIDR, which is the range from its first to last address. Empty text is the zero Range.
+func (r *Range) UnmarshalText(text []byte) error {
+	s := string(text)
+	if s == "" {
+		*r = Range{}
+		return nil
+	}
+	if strings.Contains(s, "/") {
+		n, err := CIDRToIPNet(s)
+		if err != nil {
+			return err
+		}
+		*r = Range{First: FirstAddr(n), Last: LastAddr(n)}
+		return nil
+	}
+	i := strings.IndexByte(s, '-')
+	if i < 0 {
+		return &net.ParseError{Type: "IP range", Text: s}
+	}
+	f, l := net.ParseIP(s[:i]), net.ParseIP(s[i+1:])
+	if f == nil || l == nil || checkFamily(f, l) != nil || IPBefore(l, f) {
+		return &net.ParseError{Type: "IP range", Text: s}
+	}
+	*r = Range{First: f, Last: l}
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler
+func (r Range) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler. null is the zero Range.
+func (r *Range) UnmarshalJSON(b []byte) error {
+	return unmarshalJSONText(b, r.UnmarshalText)
+}
+
+// Set is a list of Prefixes. As text it is comma separated, as JSON it is an array of strings.
+// As a flag.Value, each use of the flag adds to the Set.
+type Set []Prefix
+
+// Contains returns true if any Prefix in s contains ip
+func (s Set) Contains(ip net.IP) bool {
+	for _, p := range s {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the comma separated Prefixes in s
+func (s Set) String() string {
+	ps := make([]string, len(s))
+	for i, p := range s {
+		ps[i] = p.String()
+	}
+	return strings.Join(ps, ",")
+}
+
+// Set adds the comma separated Prefixes in v to s, implementing flag.Value
+func (s *Set) Set(v string) error {
+	ns, err := parseSet(v)
+	if err != nil {
+		return err
+	}
+	*s = append(*s, ns...)
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (s Set) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (s *Set) UnmarshalText(text []byte) error {
+	ns, err := parseSet(string(text))
+	if err != nil {
+		return err
+	}
+	*s = ns
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler
+func (s Set) MarshalJSON() ([]byte, error) {
+	if s == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal([]Prefix(s))
+}
+
+// UnmarshalJSON implements json.Unmarshaler, accepting an array or a comma separated string
+func (s *Set) UnmarshalJSON(b []byte) error {
+	if b = bytes.TrimSpace(b); len(b) == 0 || b[0] != '[' {
+		return unmarshalJSONText(b, s.UnmarshalText)
+	}
+	var ps []Prefix
+	if err := json.Unmarshal(b, &ps); err != nil {
+		return err
+	}
+	*s = ps
+	return nil
+}
+
+func parseSet(v string) (Set, error) {
+	var s Set
+	for _, f := range strings.Split(v, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		var p Prefix
+		if err := p.UnmarshalText([]byte(f)); err != nil {
+			return nil, err
+		}
+		s = append(s, p)
+	}
+	return s, nil
+}
+
+// unmarshalJSONText unmarshals a JSON string or null and passes it to f
+func unmarshalJSONText(b []byte, f func([]byte) error) error {
+	var s *string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	if s == nil {
+		return f(nil)
+	}
+	return f([]byte(*s))
+}
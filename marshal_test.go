@@ -0,0 +1,123 @@
+package iputil
+
+import (
+	"encoding/json"
+	"flag"
+	"net"
+	"testing"
+)
+
+// nolint dupl
+func TestPrefixRoundTrip(t *testing.T) {
+	var p Prefix
+	if err := p.UnmarshalText([]byte("10.1.0.1/24")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, _ := p.MarshalText()
+	if string(b) != "10.1.0.1/24" {
+		t.Errorf("%s should equal 10.1.0.1/24", b)
+	}
+}
+
+// nolint dupl
+func TestPrefixJSON(t *testing.T) {
+	var c struct {
+		Net   Prefix `json:"net"`
+		Empty Prefix `json:"empty"`
+	}
+	if err := json.Unmarshal([]byte(`{"net":"fe80::1/64","empty":null}`), &c); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !c.Net.IP.Equal(net.ParseIP("fe80::1")) {
+		t.Errorf("host bits of %v should be preserved", c.Net)
+	}
+	b, _ := json.Marshal(c)
+	if string(b) != `{"net":"fe80::1/64","empty":""}` {
+		t.Errorf("unexpected json %s", b)
+	}
+	if err := json.Unmarshal([]byte(`{"net":"bogus"}`), &c); err == nil {
+		t.Errorf("unmarshaling a bad prefix should return an error")
+	}
+}
+
+// nolint dupl
+func TestPrefixFlag(t *testing.T) {
+	var p Prefix
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Var(&p, "net", "")
+	if err := fs.Parse([]string{"-net", "10.1.0.1/24"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.String() != "10.1.0.1/24" {
+		t.Errorf("%v should equal 10.1.0.1/24", p)
+	}
+}
+
+// nolint dupl
+func TestRangeText(t *testing.T) {
+	var r Range
+	if err := r.UnmarshalText([]byte("10.1.0.10-10.1.0.20")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !r.Contains(net.ParseIP("10.1.0.20")) || r.Contains(net.ParseIP("10.1.0.21")) {
+		t.Errorf("%v should contain 10.1.0.20 and not 10.1.0.21", r)
+	}
+	if b, _ := r.MarshalText(); string(b) != "10.1.0.10-10.1.0.20" {
+		t.Errorf("%s should equal 10.1.0.10-10.1.0.20", b)
+	}
+}
+
+// nolint dupl
+func TestRangeCIDR(t *testing.T) {
+	var r Range
+	if err := r.UnmarshalText([]byte("10.1.0.1/24")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.String() != "10.1.0.0-10.1.0.255" {
+		t.Errorf("%v should equal 10.1.0.0-10.1.0.255", r)
+	}
+}
+
+// nolint dupl
+func TestRangeBad(t *testing.T) {
+	var r Range
+	for _, s := range []string{"10.1.0.20-10.1.0.10", "10.1.0.1-fe80::1", "10.1.0.1", "bogus-10.1.0.1"} {
+		if err := r.UnmarshalText([]byte(s)); err == nil {
+			t.Errorf("parsing %v should return an error", s)
+		}
+	}
+}
+
+// nolint dupl
+func TestSetJSON(t *testing.T) {
+	var s Set
+	if err := json.Unmarshal([]byte(`["10.1.0.1/24", "fe80::/64"]`), &s); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(s) != 2 || !s.Contains(net.ParseIP("fe80::5")) {
+		t.Errorf("%v should contain fe80::5", s)
+	}
+	b, _ := json.Marshal(s)
+	if string(b) != `["10.1.0.1/24","fe80::/64"]` {
+		t.Errorf("unexpected json %s", b)
+	}
+	if err := json.Unmarshal([]byte(`"10.2.0.0/16, 10.3.0.0/16"`), &s); err != nil || len(s) != 2 {
+		t.Errorf("comma separated string should unmarshal, got %v, %v", s, err)
+	}
+	if err := json.Unmarshal([]byte(`["bogus"]`), &s); err == nil {
+		t.Errorf("unmarshaling a bad prefix should return an error")
+	}
+}
+
+// nolint dupl
+func TestSetFlag(t *testing.T) {
+	var s Set
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Var(&s, "net", "")
+	if err := fs.Parse([]string{"-net", "10.1.0.0/24,10.2.0.0/24", "-net", "10.3.0.0/24"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s.String() != "10.1.0.0/24,10.2.0.0/24,10.3.0.0/24" {
+		t.Errorf("unexpected set %v", s)
+	}
+}
@@ -0,0 +1,174 @@
+package mmdb
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+)
+
+var bigIntType = reflect.TypeOf((*big.Int)(nil))
+
+// assign stores a decoded value v into the value pointed to by result
+func assign(result interface{}, v interface{}) error {
+	rv := reflect.ValueOf(result)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("mmdb: result must be a non-nil pointer, got %T", result)
+	}
+	return assignValue(rv.Elem(), v)
+}
+
+func assignValue(dst reflect.Value, v interface{}) error {
+	if v == nil {
+		return nil
+	}
+	src := reflect.ValueOf(v)
+	if dst.Type() == bigIntType {
+		if b, ok := v.(*big.Int); ok {
+			dst.Set(reflect.ValueOf(b))
+			return nil
+		}
+		if u, ok := v.(uint64); ok {
+			dst.Set(reflect.ValueOf(new(big.Int).SetUint64(u)))
+			return nil
+		}
+		return mismatch(dst, v)
+	}
+
+	switch dst.Kind() {
+	case reflect.Interface:
+		if !src.Type().Implements(dst.Type()) {
+			return mismatch(dst, v)
+		}
+		dst.Set(src)
+		return nil
+	case reflect.Ptr:
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		return assignValue(dst.Elem(), v)
+	case reflect.Struct:
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return mismatch(dst, v)
+		}
+		return assignStruct(dst, m)
+	case reflect.Map:
+		m, ok := v.(map[string]interface{})
+		if !ok || dst.Type().Key().Kind() != reflect.String {
+			return mismatch(dst, v)
+		}
+		if dst.IsNil() {
+			dst.Set(reflect.MakeMapWithSize(dst.Type(), len(m)))
+		}
+		for k, mv := range m {
+			e := reflect.New(dst.Type().Elem()).Elem()
+			if err := assignValue(e, mv); err != nil {
+				return err
+			}
+			dst.SetMapIndex(reflect.ValueOf(k).Convert(dst.Type().Key()), e)
+		}
+		return nil
+	case reflect.Slice:
+		if b, ok := v.([]byte); ok && dst.Type().Elem().Kind() == reflect.Uint8 {
+			dst.SetBytes(b)
+			return nil
+		}
+		a, ok := v.([]interface{})
+		if !ok {
+			return mismatch(dst, v)
+		}
+		s := reflect.MakeSlice(dst.Type(), len(a), len(a))
+		for i := range a {
+			if err := assignValue(s.Index(i), a[i]); err != nil {
+				return err
+			}
+		}
+		dst.Set(s)
+		return nil
+	case reflect.String:
+		if src.Kind() != reflect.String {
+			return mismatch(dst, v)
+		}
+		dst.SetString(src.String())
+		return nil
+	case reflect.Bool:
+		if src.Kind() != reflect.Bool {
+			return mismatch(dst, v)
+		}
+		dst.SetBool(src.Bool())
+		return nil
+	case reflect.Float32, reflect.Float64:
+		if src.Kind() != reflect.Float32 && src.Kind() != reflect.Float64 {
+			return mismatch(dst, v)
+		}
+		dst.SetFloat(src.Float())
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var i int64
+		switch src.Kind() {
+		case reflect.Int32:
+			i = src.Int()
+		case reflect.Uint64:
+			if src.Uint() > uint64(1<<63-1) {
+				return mismatch(dst, v)
+			}
+			i = int64(src.Uint())
+		default:
+			return mismatch(dst, v)
+		}
+		if dst.OverflowInt(i) {
+			return mismatch(dst, v)
+		}
+		dst.SetInt(i)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		var u uint64
+		switch src.Kind() {
+		case reflect.Uint64:
+			u = src.Uint()
+		case reflect.Int32:
+			if src.Int() < 0 {
+				return mismatch(dst, v)
+			}
+			u = uint64(src.Int())
+		default:
+			return mismatch(dst, v)
+		}
+		if dst.OverflowUint(u) {
+			return mismatch(dst, v)
+		}
+		dst.SetUint(u)
+		return nil
+	}
+	return mismatch(dst, v)
+}
+
+// assignStruct sets the fields of dst from m using the maxminddb tag, or the field name if there is no tag
+func assignStruct(dst reflect.Value, m map[string]interface{}) error {
+	t := dst.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		name := f.Tag.Get("maxminddb")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		mv, ok := m[name]
+		if !ok {
+			continue
+		}
+		if err := assignValue(dst.Field(i), mv); err != nil {
+			return fmt.Errorf("mmdb: field %v: %w", f.Name, err)
+		}
+	}
+	return nil
+}
+
+func mismatch(dst reflect.Value, v interface{}) error {
+	return fmt.Errorf("mmdb: cannot assign %T to %v", v, dst.Type())
+}
@@ -0,0 +1,222 @@
+package mmdb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// data types of the data section
+const (
+	typeExtended = iota
+	typePointer
+	typeString
+	typeDouble
+	typeBytes
+	typeUint16
+	typeUint32
+	typeMap
+	typeInt32
+	typeUint64
+	typeUint128
+	typeArray
+	typeContainer
+	typeEndMarker
+	typeBool
+	typeFloat
+)
+
+// maxDepth limits nesting of maps and arrays in corrupt databases
+const maxDepth = 512
+
+// decoder decodes values from a data section into Go values: string, float64, []byte, uint64,
+// map[string]interface{}, int32, *big.Int, []interface{}, bool and float32
+type decoder struct {
+	buf []byte
+}
+
+func (d *decoder) errf(format string, a ...interface{}) error {
+	return fmt.Errorf("%w: "+format, append([]interface{}{ErrInvalidDatabase}, a...)...)
+}
+
+// decode decodes the value at offset, returning it and the offset after it
+func (d *decoder) decode(offset uint) (interface{}, uint, error) {
+	return d.decodeDepth(offset, 0)
+}
+
+func (d *decoder) decodeDepth(offset uint, depth int) (interface{}, uint, error) {
+	if depth > maxDepth {
+		return nil, 0, d.errf("data nested too deeply")
+	}
+	typ, size, offset, err := d.decodeControl(offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	if typ == typePointer {
+		p, next, err := d.decodePointer(size, offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		v, _, err := d.decodeDepth(p, depth+1)
+		return v, next, err
+	}
+	return d.decodeValue(typ, size, offset, depth)
+}
+
+// decodeControl returns the type and size of the value at offset and the offset of its payload.
+// For pointers, size is the raw size bits of the control byte.
+func (d *decoder) decodeControl(offset uint) (int, uint, uint, error) {
+	if offset >= uint(len(d.buf)) {
+		return 0, 0, 0, d.errf("offset %v past end of data", offset)
+	}
+	ctrl := d.buf[offset]
+	offset++
+	typ := int(ctrl >> 5)
+	if typ == typePointer {
+		return typ, uint(ctrl & 0x1f), offset, nil
+	}
+	if typ == typeExtended {
+		if offset >= uint(len(d.buf)) {
+			return 0, 0, 0, d.errf("unexpected end of data")
+		}
+		typ = int(d.buf[offset]) + 7
+		offset++
+		if typ < typeInt32 || typ > typeFloat {
+			return 0, 0, 0, d.errf("invalid extended type %v", typ)
+		}
+	}
+	size := uint(ctrl & 0x1f)
+	if size >= 29 {
+		n := size - 28
+		b, err := d.read(offset, n)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		offset += n
+		v := uint(0)
+		for _, c := range b {
+			v = v<<8 | uint(c)
+		}
+		size = [...]uint{29, 285, 65821}[n-1] + v
+	}
+	return typ, size, offset, nil
+}
+
+// decodePointer returns the offset a pointer refers to, and the offset after the pointer
+func (d *decoder) decodePointer(size, offset uint) (uint, uint, error) {
+	ss := (size >> 3) & 0x3
+	b, err := d.read(offset, ss+1)
+	if err != nil {
+		return 0, 0, err
+	}
+	p := uint(0)
+	if ss < 3 {
+		p = size & 0x7
+	}
+	for _, c := range b {
+		p = p<<8 | uint(c)
+	}
+	p += [...]uint{0, 2048, 526336, 0}[ss]
+	return p, offset + ss + 1, nil
+}
+
+func (d *decoder) read(offset, n uint) ([]byte, error) {
+	if offset+n > uint(len(d.buf)) || offset+n < offset {
+		return nil, d.errf("unexpected end of data")
+	}
+	return d.buf[offset : offset+n], nil
+}
+
+func (d *decoder) decodeValue(typ int, size, offset uint, depth int) (interface{}, uint, error) {
+	switch typ {
+	case typeMap:
+		return d.decodeMap(size, offset, depth)
+	case typeArray:
+		return d.decodeArray(size, offset, depth)
+	case typeBool:
+		if size > 1 {
+			return nil, 0, d.errf("invalid boolean size %v", size)
+		}
+		return size == 1, offset, nil
+	case typeContainer, typeEndMarker:
+		return nil, 0, d.errf("unexpected type %v", typ)
+	}
+
+	b, err := d.read(offset, size)
+	if err != nil {
+		return nil, 0, err
+	}
+	next := offset + size
+	switch typ {
+	case typeString:
+		return string(b), next, nil
+	case typeBytes:
+		return append([]byte(nil), b...), next, nil
+	case typeDouble:
+		if size != 8 {
+			return nil, 0, d.errf("invalid double size %v", size)
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), next, nil
+	case typeFloat:
+		if size != 4 {
+			return nil, 0, d.errf("invalid float size %v", size)
+		}
+		return math.Float32frombits(binary.BigEndian.Uint32(b)), next, nil
+	case typeUint128:
+		if size > 16 {
+			return nil, 0, d.errf("invalid uint128 size %v", size)
+		}
+		return new(big.Int).SetBytes(b), next, nil
+	}
+
+	max := map[int]uint{typeUint16: 2, typeUint32: 4, typeInt32: 4, typeUint64: 8}[typ]
+	if size > max {
+		return nil, 0, d.errf("invalid size %v for type %v", size, typ)
+	}
+	var v uint64
+	for _, c := range b {
+		v = v<<8 | uint64(c)
+	}
+	if typ == typeInt32 {
+		return int32(uint32(v)), next, nil
+	}
+	return v, next, nil
+}
+
+func (d *decoder) decodeMap(size, offset uint, depth int) (interface{}, uint, error) {
+	if size > uint(len(d.buf))-offset {
+		return nil, 0, d.errf("map size %v larger than data", size)
+	}
+	m := make(map[string]interface{}, size)
+	for i := uint(0); i < size; i++ {
+		k, next, err := d.decodeDepth(offset, depth+1)
+		if err != nil {
+			return nil, 0, err
+		}
+		ks, ok := k.(string)
+		if !ok {
+			return nil, 0, d.errf("map key of type %T", k)
+		}
+		m[ks], offset, err = d.decodeDepth(next, depth+1)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	return m, offset, nil
+}
+
+func (d *decoder) decodeArray(size, offset uint, depth int) (interface{}, uint, error) {
+	if size > uint(len(d.buf))-offset {
+		return nil, 0, d.errf("array size %v larger than data", size)
+	}
+	a := make([]interface{}, size)
+	for i := range a {
+		var err error
+		a[i], offset, err = d.decodeDepth(offset, depth+1)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	return a, offset, nil
+}
@@ -0,0 +1,48 @@
+package mmdb
+
+import (
+	"errors"
+	"testing"
+)
+
+// nolint dupl
+func TestDecodePointer(t *testing.T) {
+	for _, tc := range []struct {
+		b []byte
+		e uint
+	}{
+		{[]byte{0x20 | 0x05, 0x01}, 0x501},
+		{[]byte{0x20 | 0x08 | 0x02, 0x01, 0x02}, 0x20102 + 2048},
+		{[]byte{0x20 | 0x10 | 0x03, 0x01, 0x02, 0x03}, 0x3010203 + 526336},
+		{[]byte{0x20 | 0x18, 0x01, 0x02, 0x03, 0x04}, 0x01020304},
+	} {
+		d := decoder{buf: tc.b}
+		typ, size, offset, err := d.decodeControl(0)
+		if err != nil || typ != typePointer {
+			t.Fatalf("expected a pointer, got %v, %v", typ, err)
+		}
+		p, next, err := d.decodePointer(size, offset)
+		if err != nil || p != tc.e || next != uint(len(tc.b)) {
+			t.Errorf("%v should point to %x, got %x, %v, %v", tc.b, tc.e, p, next, err)
+		}
+	}
+}
+
+// nolint dupl
+func TestDecodeTruncated(t *testing.T) {
+	for _, b := range [][]byte{
+		{0x44, 'a', 'b'},   // string of 4 bytes
+		{0x5d},             // string with missing size byte
+		{0xe2, 0x44, 'a'},  // map with a truncated key
+		{0x00},             // extended type missing
+		{0x00, 0x20},       // invalid extended type
+		{0x02, 0x02, 0x01}, // uint64 of 2 bytes
+		{0x68, 0x01, 0x02}, // double of 8 bytes
+		{0x02, 0x07},       // boolean of size 2
+	} {
+		d := decoder{buf: b}
+		if _, _, err := d.decode(0); !errors.Is(err, ErrInvalidDatabase) {
+			t.Errorf("decoding %x should return ErrInvalidDatabase, got %v", b, err)
+		}
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !unix
+
+package mmdb
+
+import "os"
+
+// mmapFile reads the whole file on platforms without mmap
+func mmapFile(path string) ([]byte, func() error, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return b, func() error { return nil }, nil
+}
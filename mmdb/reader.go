@@ -0,0 +1,189 @@
+/*
+Package mmdb implements a reader for MaxMind DB files, like the GeoIP2 and GeoLite2
+country, city and ASN databases
+*/
+package mmdb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/TrilliumIT/iputil"
+)
+
+var metadataStart = []byte("\xab\xcd\xefMaxMind.com")
+
+// metadataMaxSize is how far from the end of the file the metadata is searched for
+const metadataMaxSize = 128 * 1024
+
+// dataSectionSeparator is the number of zero bytes between the search tree and data section
+const dataSectionSeparator = 16
+
+// ErrInvalidDatabase is returned when a database file is corrupt or is not a MaxMind DB
+var ErrInvalidDatabase = errors.New("mmdb: invalid database")
+
+// Metadata describes a database
+type Metadata struct {
+	NodeCount                uint              `maxminddb:"node_count"`
+	RecordSize               uint              `maxminddb:"record_size"`
+	IPVersion                uint              `maxminddb:"ip_version"`
+	DatabaseType             string            `maxminddb:"database_type"`
+	Languages                []string          `maxminddb:"languages"`
+	BinaryFormatMajorVersion uint              `maxminddb:"binary_format_major_version"`
+	BinaryFormatMinorVersion uint              `maxminddb:"binary_format_minor_version"`
+	BuildEpoch               uint64            `maxminddb:"build_epoch"`
+	Description              map[string]string `maxminddb:"description"`
+}
+
+// Reader looks up addresses in a database
+type Reader struct {
+	Metadata  Metadata
+	buf       []byte
+	data      decoder
+	ipv4Start uint
+	unmap     func() error
+}
+
+// Open memory maps the database at path. The Reader must be closed to release the mapping.
+func Open(path string) (*Reader, error) {
+	b, unmap, err := mmapFile(path)
+	if err != nil {
+		return nil, err
+	}
+	r, err := FromBytes(b)
+	if err != nil {
+		_ = unmap()
+		return nil, err
+	}
+	r.unmap = unmap
+	return r, nil
+}
+
+// FromBytes returns a Reader for a database in b. b must not be modified while the Reader is in use.
+func FromBytes(b []byte) (*Reader, error) {
+	ms := 0
+	if len(b) > metadataMaxSize {
+		ms = len(b) - metadataMaxSize
+	}
+	i := bytes.LastIndex(b[ms:], metadataStart)
+	if i < 0 {
+		return nil, fmt.Errorf("%w: metadata not found", ErrInvalidDatabase)
+	}
+	i += ms + len(metadataStart)
+
+	r := &Reader{buf: b}
+	md := decoder{buf: b[i:]}
+	v, _, err := md.decode(0)
+	if err != nil {
+		return nil, err
+	}
+	if err := assign(&r.Metadata, v); err != nil {
+		return nil, err
+	}
+	m := &r.Metadata
+	if m.RecordSize != 24 && m.RecordSize != 28 && m.RecordSize != 32 {
+		return nil, fmt.Errorf("%w: unsupported record size %v", ErrInvalidDatabase, m.RecordSize)
+	}
+	if m.IPVersion != 4 && m.IPVersion != 6 {
+		return nil, fmt.Errorf("%w: unsupported ip version %v", ErrInvalidDatabase, m.IPVersion)
+	}
+	// each node is RecordSize/4 bytes, checked before multiplying so it can't overflow
+	if m.NodeCount > uint(len(b))/(m.RecordSize/4) {
+		return nil, fmt.Errorf("%w: search tree larger than file", ErrInvalidDatabase)
+	}
+	treeSize := m.NodeCount * (m.RecordSize / 4)
+	if treeSize+dataSectionSeparator > uint(i-len(metadataStart)) {
+		return nil, fmt.Errorf("%w: search tree overlaps metadata", ErrInvalidDatabase)
+	}
+	r.data = decoder{buf: b[treeSize+dataSectionSeparator : i-len(metadataStart)]}
+
+	if m.IPVersion == 6 {
+		// IPv4 addresses are stored in ::/96
+		for i := 0; i < 96 && r.ipv4Start < m.NodeCount; i++ {
+			r.ipv4Start = r.readNode(r.ipv4Start, 0)
+		}
+	}
+	return r, nil
+}
+
+// Close releases the memory mapping of a Reader from Open
+func (r *Reader) Close() error {
+	if r.unmap == nil {
+		return nil
+	}
+	err := r.unmap()
+	r.unmap, r.buf = nil, nil
+	return err
+}
+
+// Lookup finds the record for ip and decodes it into result, which may be a pointer to
+// an interface{}, map or struct. Struct fields are matched using the maxminddb tag.
+// The network containing ip which shares the record is returned, along with whether a record was found.
+func (r *Reader) Lookup(ip net.IP, result interface{}) (*net.IPNet, bool, error) {
+	if r.buf == nil {
+		return nil, false, errors.New("mmdb: lookup on closed reader")
+	}
+	rec, n, err := r.lookupRecord(ip)
+	if err != nil || rec == 0 {
+		return n, false, err
+	}
+	v, _, err := r.data.decode(rec - r.Metadata.NodeCount - dataSectionSeparator)
+	if err != nil {
+		return n, false, err
+	}
+	return n, true, assign(result, v)
+}
+
+// lookupRecord walks the search tree for ip. It returns the data record, or 0 if
+// ip is not in the database, and the network at the depth the walk ended.
+func (r *Reader) lookupRecord(ip net.IP) (uint, *net.IPNet, error) {
+	bits, node := 128, uint(0)
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+		bits = 32
+		if r.Metadata.IPVersion == 6 {
+			// if the tree ends above ::/96, the walk below ends immediately with a /0
+			node = r.ipv4Start
+		}
+	} else if len(ip) != net.IPv6len {
+		return 0, nil, iputil.ErrFamilyMismatch
+	} else if r.Metadata.IPVersion == 4 {
+		return 0, nil, iputil.ErrFamilyMismatch
+	}
+
+	nc := r.Metadata.NodeCount
+	i := 0
+	for ; i < bits && node < nc; i++ {
+		bit := uint(ip[i>>3]>>(7-uint(i&7))) & 1
+		node = r.readNode(node, bit)
+	}
+	n := iputil.NetworkID(&net.IPNet{IP: ip, Mask: net.CIDRMask(i, bits)})
+	switch {
+	case node == nc:
+		return 0, n, nil
+	case node > nc:
+		return node, n, nil
+	}
+	return 0, nil, fmt.Errorf("%w: invalid node in search tree", ErrInvalidDatabase)
+}
+
+// readNode returns the left (bit 0) or right (bit 1) record of a node
+func (r *Reader) readNode(node, bit uint) uint {
+	switch r.Metadata.RecordSize {
+	case 24:
+		o := node*6 + bit*3
+		b := r.buf[o : o+3]
+		return uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+	case 28:
+		b := r.buf[node*7 : node*7+7]
+		if bit == 0 {
+			return uint(b[3]&0xf0)<<20 | uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+		}
+		return uint(b[3]&0x0f)<<24 | uint(b[4])<<16 | uint(b[5])<<8 | uint(b[6])
+	}
+	o := node*8 + bit*4
+	b := r.buf[o : o+4]
+	return uint(b[0])<<24 | uint(b[1])<<16 | uint(b[2])<<8 | uint(b[3])
+}
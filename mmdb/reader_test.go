@@ -0,0 +1,213 @@
+package mmdb
+
+import (
+	"errors"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/TrilliumIT/iputil"
+)
+
+type testRecord struct {
+	Country struct {
+		ISOCode string            `maxminddb:"iso_code"`
+		Names   map[string]string `maxminddb:"names"`
+	} `maxminddb:"country"`
+	ASN      uint      `maxminddb:"asn"`
+	Org      string    `maxminddb:"org"`
+	Anycast  bool      `maxminddb:"anycast"`
+	Location []float64 `maxminddb:"loc"`
+	Big      *big.Int  `maxminddb:"big"`
+	Offset   int       `maxminddb:"offset"`
+	Name     *string   `maxminddb:"name"`
+}
+
+var testOrg = strings.Repeat("Example Organization ", 20)
+
+func testDB(t *testing.T, recordSize, ipVersion uint) []byte {
+	records := []fixtureRecord{
+		{"1.2.3.0/24", map[string]interface{}{
+			"country": map[string]interface{}{
+				"iso_code": "US",
+				"names":    map[string]interface{}{"en": "United States"},
+			},
+			"asn":     uint32(15169),
+			"org":     testOrg,
+			"anycast": true,
+			"loc":     []interface{}{1.5, 2.5},
+			"big":     new(big.Int).Lsh(big.NewInt(1), 100),
+			"offset":  int32(-5),
+			"name":    pointer(0),
+		}},
+		{"10.0.0.0/8", map[string]interface{}{"asn": uint64(64500), "name": pointer(0)}},
+	}
+	if ipVersion == 6 {
+		records = append(records, fixtureRecord{"2001:db8::/32", map[string]interface{}{
+			"asn":  uint16(64501),
+			"name": pointer(0),
+		}})
+	}
+	return buildDB(t, recordSize, ipVersion, "shared name", records)
+}
+
+// nolint dupl
+func TestMetadata(t *testing.T) {
+	r, err := FromBytes(testDB(t, 24, 6))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	m := r.Metadata
+	if m.DatabaseType != "iputil-test" || m.IPVersion != 6 || m.RecordSize != 24 || m.BuildEpoch != 1500000000 {
+		t.Errorf("unexpected metadata %+v", m)
+	}
+	if m.Description["en"] != "iputil test database" || len(m.Languages) != 1 {
+		t.Errorf("unexpected metadata %+v", m)
+	}
+}
+
+// nolint dupl
+func TestLookupStruct(t *testing.T) {
+	for _, rs := range []uint{24, 28, 32} {
+		for _, v := range []uint{4, 6} {
+			r, err := FromBytes(testDB(t, rs, v))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var rec testRecord
+			n, ok, err := r.Lookup(net.ParseIP("1.2.3.4"), &rec)
+			if err != nil || !ok {
+				t.Fatalf("record size %v ip version %v: expected a record, got %v, %v", rs, v, ok, err)
+			}
+			if n.String() != "1.2.3.0/24" {
+				t.Errorf("network %v should equal 1.2.3.0/24", n)
+			}
+			if rec.Country.ISOCode != "US" || rec.Country.Names["en"] != "United States" || rec.ASN != 15169 {
+				t.Errorf("unexpected record %+v", rec)
+			}
+			if rec.Org != testOrg || !rec.Anycast || len(rec.Location) != 2 || rec.Location[1] != 2.5 {
+				t.Errorf("unexpected record %+v", rec)
+			}
+			if rec.Big.BitLen() != 101 || rec.Offset != -5 || rec.Name == nil || *rec.Name != "shared name" {
+				t.Errorf("unexpected record %+v", rec)
+			}
+		}
+	}
+}
+
+// nolint dupl
+func TestLookupMap6(t *testing.T) {
+	r, err := FromBytes(testDB(t, 28, 6))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var rec map[string]interface{}
+	n, ok, err := r.Lookup(net.ParseIP("2001:db8::1"), &rec)
+	if err != nil || !ok {
+		t.Fatalf("expected a record, got %v, %v", ok, err)
+	}
+	if n.String() != "2001:db8::/32" {
+		t.Errorf("network %v should equal 2001:db8::/32", n)
+	}
+	if rec["asn"] != uint64(64501) || rec["name"] != "shared name" {
+		t.Errorf("unexpected record %v", rec)
+	}
+}
+
+// nolint dupl
+func TestLookupNotFound(t *testing.T) {
+	r, err := FromBytes(testDB(t, 24, 6))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var rec interface{}
+	n, ok, err := r.Lookup(net.ParseIP("1.2.4.1"), &rec)
+	if err != nil || ok {
+		t.Fatalf("expected no record, got %v, %v", ok, err)
+	}
+	if !n.Contains(net.ParseIP("1.2.4.1")) {
+		t.Errorf("network %v should contain 1.2.4.1", n)
+	}
+	if _, ok, _ := r.Lookup(net.ParseIP("2001:db9::1"), &rec); ok {
+		t.Errorf("expected no record for 2001:db9::1")
+	}
+}
+
+// nolint dupl
+func TestLookupFamilyMismatch(t *testing.T) {
+	r, err := FromBytes(testDB(t, 24, 4))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var rec interface{}
+	if _, _, err := r.Lookup(net.ParseIP("2001:db8::1"), &rec); err != iputil.ErrFamilyMismatch {
+		t.Errorf("Expected ErrFamilyMismatch, got %v", err)
+	}
+}
+
+// nolint dupl
+func TestLookupTypeMismatch(t *testing.T) {
+	r, err := FromBytes(testDB(t, 24, 6))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var rec struct {
+		ASN string `maxminddb:"asn"`
+	}
+	if _, _, err := r.Lookup(net.ParseIP("10.1.1.1"), &rec); err == nil {
+		t.Errorf("decoding a uint into a string should return an error")
+	}
+	if _, _, err := r.Lookup(net.ParseIP("10.1.1.1"), rec); err == nil {
+		t.Errorf("decoding into a non-pointer should return an error")
+	}
+}
+
+// nolint dupl
+func TestOpen(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "test.mmdb")
+	if err := os.WriteFile(p, testDB(t, 32, 6), 0600); err != nil {
+		t.Fatal(err)
+	}
+	r, err := Open(p)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var asn struct {
+		ASN uint32 `maxminddb:"asn"`
+	}
+	if _, ok, err := r.Lookup(net.ParseIP("10.1.1.1"), &asn); err != nil || !ok || asn.ASN != 64500 {
+		t.Errorf("expected asn 64500, got %v, %v, %v", asn.ASN, ok, err)
+	}
+	if err := r.Close(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, _, err := r.Lookup(net.ParseIP("10.1.1.1"), &asn); err == nil {
+		t.Errorf("lookup on a closed reader should return an error")
+	}
+}
+
+// nolint dupl
+func TestInvalidDatabase(t *testing.T) {
+	if _, err := FromBytes([]byte("not a database")); !errors.Is(err, ErrInvalidDatabase) {
+		t.Errorf("Expected ErrInvalidDatabase, got %v", err)
+	}
+	b := testDB(t, 24, 6)
+	b = b[len(b)-200:]
+	if _, err := FromBytes(b); !errors.Is(err, ErrInvalidDatabase) {
+		t.Errorf("Expected ErrInvalidDatabase for a truncated database, got %v", err)
+	}
+	for _, nc := range []uint64{3, 1 << 62} {
+		b := append(make([]byte, 10), metadataStart...)
+		b = append(b, encodeValue(t, map[string]interface{}{
+			"node_count":  nc,
+			"record_size": uint16(24),
+			"ip_version":  uint16(4),
+		})...)
+		if _, err := FromBytes(b); !errors.Is(err, ErrInvalidDatabase) {
+			t.Errorf("%v nodes: Expected ErrInvalidDatabase for a tree past the metadata, got %v", nc, err)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package mmdb
+
+import (
+	"encoding/binary"
+	"math"
+	"math/big"
+	"net"
+	"sort"
+	"testing"
+)
+
+// pointer is encoded as a data section pointer to an offset
+type pointer uint
+
+// fixtureRecord is a network and the value stored for it in a fixture database
+type fixtureRecord struct {
+	cidr  string
+	value interface{}
+}
+
+// encodeControl encodes the control byte and size of a value
+func encodeControl(typ int, size int) []byte {
+	var ext []byte
+	switch {
+	case size < 29:
+	case size < 285:
+		ext, size = []byte{byte(size - 29)}, 29
+	case size < 65821:
+		s := size - 285
+		ext, size = []byte{byte(s >> 8), byte(s)}, 30
+	default:
+		s := size - 65821
+		ext, size = []byte{byte(s >> 16), byte(s >> 8), byte(s)}, 31
+	}
+	var b []byte
+	if typ > 7 {
+		b = []byte{byte(size), byte(typ - 7)}
+	} else {
+		b = []byte{byte(typ<<5 | size)}
+	}
+	return append(b, ext...)
+}
+
+func trimUint(u uint64, n int) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, u)
+	b = b[8-n:]
+	for len(b) > 0 && b[0] == 0 {
+		b = b[1:]
+	}
+	return b
+}
+
+// encodeValue encodes v in the data section format
+func encodeValue(t *testing.T, v interface{}) []byte {
+	switch v := v.(type) {
+	case pointer:
+		if v >= 2048 {
+			t.Fatalf("pointer %v too large", v)
+		}
+		return []byte{byte(typePointer<<5 | v>>8), byte(v)}
+	case string:
+		return append(encodeControl(typeString, len(v)), v...)
+	case []byte:
+		return append(encodeControl(typeBytes, len(v)), v...)
+	case float64:
+		b := encodeControl(typeDouble, 8)
+		return binary.BigEndian.AppendUint64(b, math.Float64bits(v))
+	case float32:
+		b := encodeControl(typeFloat, 4)
+		return binary.BigEndian.AppendUint32(b, math.Float32bits(v))
+	case uint16:
+		b := trimUint(uint64(v), 2)
+		return append(encodeControl(typeUint16, len(b)), b...)
+	case uint32:
+		b := trimUint(uint64(v), 4)
+		return append(encodeControl(typeUint32, len(b)), b...)
+	case uint64:
+		b := trimUint(v, 8)
+		return append(encodeControl(typeUint64, len(b)), b...)
+	case int32:
+		b := trimUint(uint64(uint32(v)), 4)
+		return append(encodeControl(typeInt32, len(b)), b...)
+	case *big.Int:
+		b := v.Bytes()
+		return append(encodeControl(typeUint128, len(b)), b...)
+	case bool:
+		if v {
+			return encodeControl(typeBool, 1)
+		}
+		return encodeControl(typeBool, 0)
+	case []interface{}:
+		b := encodeControl(typeArray, len(v))
+		for _, e := range v {
+			b = append(b, encodeValue(t, e)...)
+		}
+		return b
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		b := encodeControl(typeMap, len(v))
+		for _, k := range keys {
+			b = append(b, encodeValue(t, k)...)
+			b = append(b, encodeValue(t, v[k])...)
+		}
+		return b
+	}
+	t.Fatalf("cannot encode %T", v)
+	return nil
+}
+
+// buildDB builds a database from records. shared is written at the start of the data section
+// so records can refer to it with a pointer to offset 0.
+func buildDB(t *testing.T, recordSize, ipVersion uint, shared interface{}, records []fixtureRecord) []byte {
+	const (
+		empty = iota
+		node
+		data
+	)
+	type rec struct {
+		kind int
+		v    uint
+	}
+	nodes := [][2]rec{{}}
+
+	ds := encodeValue(t, shared)
+	for _, fr := range records {
+		ip, n, err := net.ParseCIDR(fr.cidr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ones, bits := n.Mask.Size()
+		key := ip.To16()
+		if bits == 32 {
+			key = append(make(net.IP, 12), ip.To4()...)
+			ones += 96
+		}
+		if ipVersion == 4 {
+			key = ip.To4()
+			ones -= 96
+		}
+		off := uint(len(ds))
+		ds = append(ds, encodeValue(t, fr.value)...)
+
+		cur := 0
+		for i := 0; i < ones; i++ {
+			bit := key[i/8] >> (7 - uint(i%8)) & 1
+			if i == ones-1 {
+				nodes[cur][bit] = rec{kind: data, v: off}
+				break
+			}
+			if nodes[cur][bit].kind != node {
+				nodes = append(nodes, [2]rec{})
+				nodes[cur][bit] = rec{kind: node, v: uint(len(nodes) - 1)}
+			}
+			cur = int(nodes[cur][bit].v)
+		}
+	}
+
+	nc := uint(len(nodes))
+	var tree []byte
+	for _, n := range nodes {
+		var vs [2]uint
+		for i, r := range n {
+			switch r.kind {
+			case empty:
+				vs[i] = nc
+			case node:
+				vs[i] = r.v
+			case data:
+				vs[i] = nc + dataSectionSeparator + r.v
+			}
+		}
+		switch recordSize {
+		case 24:
+			tree = append(tree, byte(vs[0]>>16), byte(vs[0]>>8), byte(vs[0]),
+				byte(vs[1]>>16), byte(vs[1]>>8), byte(vs[1]))
+		case 28:
+			tree = append(tree, byte(vs[0]>>16), byte(vs[0]>>8), byte(vs[0]),
+				byte(vs[0]>>20&0xf0|vs[1]>>24&0x0f),
+				byte(vs[1]>>16), byte(vs[1]>>8), byte(vs[1]))
+		case 32:
+			tree = binary.BigEndian.AppendUint32(tree, uint32(vs[0]))
+			tree = binary.BigEndian.AppendUint32(tree, uint32(vs[1]))
+		}
+	}
+
+	b := append(tree, make([]byte, dataSectionSeparator)...)
+	b = append(b, ds...)
+	b = append(b, metadataStart...)
+	return append(b, encodeValue(t, map[string]interface{}{
+		"node_count":                  uint32(nc),
+		"record_size":                 uint16(recordSize),
+		"ip_version":                  uint16(ipVersion),
+		"database_type":               "iputil-test",
+		"languages":                   []interface{}{"en"},
+		"binary_format_major_version": uint16(2),
+		"binary_format_minor_version": uint16(0),
+		"build_epoch":                 uint64(1500000000),
+		"description":                 map[string]interface{}{"en": "iputil test database"},
+	})...)
+}
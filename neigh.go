@@ -0,0 +1,113 @@
+package iputil
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// arpComplete is ATF_COM from linux/if_arp.h, set when the hardware address is known
+const arpComplete = 0x2
+
+// Neighbor is an entry in the kernel neighbor (ARP or NDP) table
+type Neighbor struct {
+	IP           net.IP
+	HardwareAddr net.HardwareAddr
+	Iface        string
+	State        string // State is the neighbor state, like REACHABLE, STALE or FAILED
+}
+
+// Occupied returns true if the neighbor was resolved to a hardware address, so its
+// address is in use. Failed and incomplete entries are not occupied.
+func (n Neighbor) Occupied() bool {
+	if len(n.HardwareAddr) == 0 {
+		return false
+	}
+	switch n.State {
+	case "FAILED", "INCOMPLETE", "NONE":
+		return false
+	}
+	return true
+}
+
+// Neighbors is a neighbor table
+type Neighbors []Neighbor
+
+// Occupied returns true if ip is in use by a neighbor. It can be used as an ExcludeFunc
+// so allocations skip addresses that are already in use.
+func (ns Neighbors) Occupied(ip net.IP) bool {
+	for _, n := range ns {
+		if n.IP.Equal(ip) && n.Occupied() {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseARP parses the IPv4 neighbor table in the format of /proc/net/arp
+func ParseARP(r io.Reader) (Neighbors, error) {
+	var ns Neighbors
+	s := bufio.NewScanner(r)
+	for line := 1; s.Scan(); line++ {
+		f := strings.Fields(s.Text())
+		if line == 1 || len(f) == 0 {
+			continue
+		}
+		if len(f) < 6 {
+			return nil, fmt.Errorf("iputil: arp line %v: expected 6 fields, got %v", line, len(f))
+		}
+		ip := net.ParseIP(f[0])
+		if ip == nil {
+			return nil, fmt.Errorf("iputil: arp line %v: invalid address %q", line, f[0])
+		}
+		flags, err := strconv.ParseUint(f[2], 0, 32)
+		if err != nil {
+			return nil, fmt.Errorf("iputil: arp line %v: %w", line, err)
+		}
+		n := Neighbor{IP: ip, Iface: f[5], State: "INCOMPLETE"}
+		if flags&arpComplete != 0 {
+			if n.HardwareAddr, err = net.ParseMAC(f[3]); err != nil {
+				return nil, fmt.Errorf("iputil: arp line %v: %w", line, err)
+			}
+			n.State = "REACHABLE"
+		}
+		ns = append(ns, n)
+	}
+	return ns, s.Err()
+}
+
+// ParseIPNeighJSON parses the IPv4 and IPv6 neighbor table in the output of `ip -j neigh`
+func ParseIPNeighJSON(r io.Reader) (Neighbors, error) {
+	var entries []struct {
+		Dst    string   `json:"dst"`
+		Dev    string   `json:"dev"`
+		LLAddr string   `json:"lladdr"`
+		State  []string `json:"state"`
+	}
+	if err := json.NewDecoder(r).Decode(&entries); err != nil {
+		return nil, err
+	}
+	ns := make(Neighbors, 0, len(entries))
+	for _, e := range entries {
+		ip := net.ParseIP(e.Dst)
+		if ip == nil {
+			return nil, fmt.Errorf("iputil: invalid neighbor address %q", e.Dst)
+		}
+		n := Neighbor{IP: ip, Iface: e.Dev}
+		if len(e.State) > 0 {
+			n.State = e.State[0]
+		}
+		if e.LLAddr != "" {
+			var err error
+			if n.HardwareAddr, err = net.ParseMAC(e.LLAddr); err != nil {
+				return nil, err
+			}
+		}
+		ns = append(ns, n)
+	}
+	return ns, nil
+}
@@ -0,0 +1,27 @@
+package iputil
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+)
+
+// ReadARP reads the IPv4 neighbor table from /proc/net/arp
+func ReadARP() (Neighbors, error) {
+	f, err := os.Open("/proc/net/arp")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseARP(f)
+}
+
+// ReadIPNeigh reads the IPv4 and IPv6 neighbor tables by running `ip -j neigh show`
+func ReadIPNeigh(ctx context.Context) (Neighbors, error) {
+	out, err := exec.CommandContext(ctx, "ip", "-j", "neigh", "show").Output()
+	if err != nil {
+		return nil, err
+	}
+	return ParseIPNeighJSON(bytes.NewReader(out))
+}
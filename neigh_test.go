@@ -0,0 +1,92 @@
+package iputil
+
+import (
+	"io"
+	"net"
+	"os"
+	"strings"
+	"testing"
+)
+
+func testNeighbors(t *testing.T, path string, parse func(io.Reader) (Neighbors, error)) Neighbors {
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	ns, err := parse(f)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return ns
+}
+
+// nolint dupl
+func TestParseARP(t *testing.T) {
+	ns := testNeighbors(t, "testdata/arp", ParseARP)
+	if len(ns) != 3 {
+		t.Fatalf("expected 3 neighbors, got %v", ns)
+	}
+	if ns[0].HardwareAddr.String() != "00:11:22:33:44:55" || ns[0].Iface != "eth0" {
+		t.Errorf("unexpected neighbor %+v", ns[0])
+	}
+	for _, tc := range []struct {
+		ip string
+		e  bool
+	}{
+		{"192.168.1.1", true},
+		{"192.168.1.7", false},
+		{"192.168.1.9", true},
+		{"192.168.1.10", false},
+	} {
+		if ns.Occupied(net.ParseIP(tc.ip)) != tc.e {
+			t.Errorf("%v should have occupied %v", tc.ip, tc.e)
+		}
+	}
+}
+
+// nolint dupl
+func TestParseIPNeighJSON(t *testing.T) {
+	ns := testNeighbors(t, "testdata/ip-neigh.json", ParseIPNeighJSON)
+	if len(ns) != 4 {
+		t.Fatalf("expected 4 neighbors, got %v", ns)
+	}
+	for _, tc := range []struct {
+		ip string
+		e  bool
+	}{
+		{"192.168.1.1", true},
+		{"192.168.1.8", false},
+		{"fe80::1", true},
+		{"fe80::2", false},
+	} {
+		if ns.Occupied(net.ParseIP(tc.ip)) != tc.e {
+			t.Errorf("%v should have occupied %v", tc.ip, tc.e)
+		}
+	}
+}
+
+// nolint dupl
+func TestParseARPBad(t *testing.T) {
+	if _, err := ParseARP(strings.NewReader("header\nbogus 0x1 0x2 00:11:22:33:44:55 * eth0\n")); err == nil {
+		t.Errorf("parsing a bad address should return an error")
+	}
+	if _, err := ParseIPNeighJSON(strings.NewReader(`[{"dst":"bogus"}]`)); err == nil {
+		t.Errorf("parsing a bad address should return an error")
+	}
+}
+
+// nolint dupl
+func TestNeighborsExclude(t *testing.T) {
+	ns := Neighbors{
+		{IP: net.ParseIP("10.1.0.1"), HardwareAddr: net.HardwareAddr{0, 1, 2, 3, 4, 5}, State: "REACHABLE"},
+		{IP: net.ParseIP("10.1.0.2"), HardwareAddr: net.HardwareAddr{0, 1, 2, 3, 4, 6}, State: "STALE"},
+	}
+	_, sn, _ := net.ParseCIDR("10.1.0.0/30")
+	for i := 0; i < 10; i++ {
+		ip, err := RandAddrWithExcludeFunc(sn, 1, 0, ns.Occupied)
+		if err != nil || !ip.Equal(net.ParseIP("10.1.0.3")) {
+			t.Errorf("expected 10.1.0.3, got %v, %v", ip, err)
+		}
+	}
+}
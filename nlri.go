@@ -0,0 +1,126 @@
+package iputil
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+)
+
+// NLRIPath is a prefix with the path identifier used by BGP ADD-PATH (RFC 7911)
+type NLRIPath struct {
+	PathID uint32
+	Net    *net.IPNet
+}
+
+// EncodeNLRI encodes nets in the BGP NLRI format of RFC 4271, each prefix is a length
+// byte followed by the fewest octets that hold the prefix. All nets must be of the same
+// family and have no host bits set, returning ErrFamilyMismatch or ErrHostBits.
+func EncodeNLRI(nets []*net.IPNet) ([]byte, error) {
+	var b []byte
+	bits := 0
+	for _, n := range nets {
+		var err error
+		if b, err = appendNLRI(b, n, &bits); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// DecodeNLRI decodes the BGP NLRI format into IPNets. ipLen is the length of
+// addresses in the family being decoded, net.IPv4len or net.IPv6len.
+func DecodeNLRI(b []byte, ipLen int) ([]*net.IPNet, error) {
+	if ipLen != net.IPv4len && ipLen != net.IPv6len {
+		return nil, ErrFamilyMismatch
+	}
+	var nets []*net.IPNet
+	for len(b) > 0 {
+		n, l, err := decodeNLRI(b, ipLen)
+		if err != nil {
+			return nil, err
+		}
+		nets = append(nets, n)
+		b = b[l:]
+	}
+	return nets, nil
+}
+
+// EncodeNLRIAddPath encodes paths in the BGP ADD-PATH NLRI format of RFC 7911,
+// which prefixes each NLRI with a 4 byte path identifier
+func EncodeNLRIAddPath(paths []NLRIPath) ([]byte, error) {
+	var b []byte
+	bits := 0
+	for _, p := range paths {
+		b = binary.BigEndian.AppendUint32(b, p.PathID)
+		var err error
+		if b, err = appendNLRI(b, p.Net, &bits); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// DecodeNLRIAddPath decodes the BGP ADD-PATH NLRI format. ipLen is the length of
+// addresses in the family being decoded, net.IPv4len or net.IPv6len.
+func DecodeNLRIAddPath(b []byte, ipLen int) ([]NLRIPath, error) {
+	if ipLen != net.IPv4len && ipLen != net.IPv6len {
+		return nil, ErrFamilyMismatch
+	}
+	var paths []NLRIPath
+	for len(b) > 0 {
+		if len(b) < 4 {
+			return nil, io.ErrUnexpectedEOF
+		}
+		id := binary.BigEndian.Uint32(b)
+		n, l, err := decodeNLRI(b[4:], ipLen)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, NLRIPath{PathID: id, Net: n})
+		b = b[4+l:]
+	}
+	return paths, nil
+}
+
+// appendNLRI appends the encoding of n to b. family is the address size of the
+// previously appended nets, or 0 for the first.
+func appendNLRI(b []byte, n *net.IPNet, family *int) ([]byte, error) {
+	if err := checkNet(n); err != nil {
+		return nil, err
+	}
+	if err := checkHostBits(n); err != nil {
+		return nil, err
+	}
+	ones, bits := netSize(n)
+	if *family != 0 && *family != bits {
+		return nil, ErrFamilyMismatch
+	}
+	*family = bits
+	ip := n.IP.To4()
+	if bits == 128 {
+		ip = n.IP.To16()
+	}
+	b = append(b, byte(ones))
+	return append(b, ip[:(ones+7)/8]...), nil
+}
+
+// decodeNLRI decodes a single prefix from the start of b, returning it and its encoded length
+func decodeNLRI(b []byte, ipLen int) (*net.IPNet, int, error) {
+	if len(b) == 0 {
+		return nil, 0, io.ErrUnexpectedEOF
+	}
+	ones := int(b[0])
+	if ones > ipLen*8 {
+		return nil, 0, ErrInvalidMask
+	}
+	l := (ones + 7) / 8
+	if len(b) < 1+l {
+		return nil, 0, io.ErrUnexpectedEOF
+	}
+	n := &net.IPNet{IP: make(net.IP, ipLen), Mask: net.CIDRMask(ones, ipLen*8)}
+	copy(n.IP, b[1:1+l])
+	if err := checkHostBits(n); err != nil {
+		return nil, 0, err
+	}
+	return n, 1 + l, nil
+}
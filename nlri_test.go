@@ -0,0 +1,106 @@
+package iputil
+
+import (
+	"io"
+	"net"
+	"testing"
+)
+
+// nolint dupl
+func TestEncodeNLRI(t *testing.T) {
+	_, net1, _ := net.ParseCIDR("10.1.0.0/16")
+	_, net2, _ := net.ParseCIDR("192.168.1.128/25")
+	_, net3, _ := net.ParseCIDR("0.0.0.0/0")
+	b, err := EncodeNLRI([]*net.IPNet{net1, net2, net3})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	e := []byte{16, 10, 1, 25, 192, 168, 1, 128, 0}
+	if string(b) != string(e) {
+		t.Errorf("expected %v, got %v", e, b)
+	}
+}
+
+// nolint dupl
+func TestEncodeNLRIHostBits(t *testing.T) {
+	n, _ := CIDRToIPNet("10.1.0.1/16")
+	if _, err := EncodeNLRI([]*net.IPNet{n}); err != ErrHostBits {
+		t.Errorf("Expected ErrHostBits, got %v", err)
+	}
+}
+
+// nolint dupl
+func TestEncodeNLRIFamilyMismatch(t *testing.T) {
+	_, net1, _ := net.ParseCIDR("10.1.0.0/16")
+	_, net2, _ := net.ParseCIDR("fe80::/64")
+	if _, err := EncodeNLRI([]*net.IPNet{net1, net2}); err != ErrFamilyMismatch {
+		t.Errorf("Expected ErrFamilyMismatch, got %v", err)
+	}
+}
+
+// nolint dupl
+func TestDecodeNLRI(t *testing.T) {
+	nets, err := DecodeNLRI([]byte{16, 10, 1, 25, 192, 168, 1, 128, 0}, net.IPv4len)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	e := []string{"10.1.0.0/16", "192.168.1.128/25", "0.0.0.0/0"}
+	if len(nets) != len(e) {
+		t.Fatalf("expected %v nets, got %v", len(e), nets)
+	}
+	for i := range e {
+		if nets[i].String() != e[i] {
+			t.Errorf("%v should equal %v", nets[i], e[i])
+		}
+	}
+}
+
+// nolint dupl
+func TestDecodeNLRI6(t *testing.T) {
+	nets, err := DecodeNLRI([]byte{48, 0x20, 0x01, 0x0d, 0xb8, 0, 1}, net.IPv6len)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(nets) != 1 || nets[0].String() != "2001:db8:1::/48" {
+		t.Errorf("expected 2001:db8:1::/48, got %v", nets)
+	}
+}
+
+// nolint dupl
+func TestDecodeNLRIBad(t *testing.T) {
+	if _, err := DecodeNLRI([]byte{24, 10, 1}, net.IPv4len); err != io.ErrUnexpectedEOF {
+		t.Errorf("Expected io.ErrUnexpectedEOF, got %v", err)
+	}
+	if _, err := DecodeNLRI([]byte{33, 10, 1, 0, 0, 0}, net.IPv4len); err != ErrInvalidMask {
+		t.Errorf("Expected ErrInvalidMask, got %v", err)
+	}
+	if _, err := DecodeNLRI([]byte{23, 10, 1, 1}, net.IPv4len); err != ErrHostBits {
+		t.Errorf("Expected ErrHostBits, got %v", err)
+	}
+}
+
+// nolint dupl
+func TestNLRIAddPath(t *testing.T) {
+	_, net1, _ := net.ParseCIDR("10.1.0.0/16")
+	b, err := EncodeNLRIAddPath([]NLRIPath{{PathID: 1, Net: net1}, {PathID: 258, Net: net1}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	e := []byte{0, 0, 0, 1, 16, 10, 1, 0, 0, 1, 2, 16, 10, 1}
+	if string(b) != string(e) {
+		t.Errorf("expected %v, got %v", e, b)
+	}
+	paths, err := DecodeNLRIAddPath(b, net.IPv4len)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(paths) != 2 || paths[1].PathID != 258 || !SubnetEqualSubnet(paths[1].Net, net1) {
+		t.Errorf("unexpected paths %v", paths)
+	}
+	if _, err := DecodeNLRIAddPath(b[:2], net.IPv4len); err != io.ErrUnexpectedEOF {
+		t.Errorf("Expected io.ErrUnexpectedEOF, got %v", err)
+	}
+	if _, err := DecodeNLRIAddPath(b[:4], net.IPv4len); err != io.ErrUnexpectedEOF {
+		t.Errorf("Expected io.ErrUnexpectedEOF for a path ID without a prefix, got %v", err)
+	}
+}
@@ -0,0 +1,137 @@
+package iputil
+
+import (
+	"encoding/binary"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// ParseStrict parses an address with an optional prefix length, like 10.1.0.1/24 or 2001:db8::1,
+// into an IPNet keeping the host bits of the address, like CIDRToIPNet. IPv4 addresses must
+// be 4 decimal octets. Leading zeros, which some parsers read as octal, zones, signs and
+// whitespace are rejected. An address without a prefix length is a /32 or /128.
+func ParseStrict(s string) (*net.IPNet, error) {
+	addr, plen, hasLen := strings.Cut(s, "/")
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return nil, &net.ParseError{Type: "IP address", Text: s}
+	}
+	bits := 8 * net.IPv6len
+	if !strings.Contains(addr, ":") {
+		ip, bits = ip.To4(), 8*net.IPv4len
+	}
+	ones := bits
+	if hasLen {
+		var ok bool
+		if ones, ok = parsePrefixLen(plen, bits); !ok || (len(plen) > 1 && plen[0] == '0') {
+			return nil, &net.ParseError{Type: "CIDR address", Text: s}
+		}
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(ones, bits)}, nil
+}
+
+// ParseLoose parses an address and optional mask like ParseStrict, but also accepts the legacy
+// forms found in old configuration and inventory data:
+//
+//	10.0.0.0/255.255.0.0    a dotted mask in place of the prefix length
+//	10.0.0.0 255.255.0.0    an address and mask separated by whitespace
+//	10.1                    IPv4 addresses as inet_aton reads them, so 10.1 is 10.0.0.1
+//	0x0a.012.0.1            hexadecimal and octal parts, so this is 10.10.0.1
+//
+// As with inet_aton, an IPv4 part with a leading zero is octal. Surrounding whitespace is ignored.
+// ErrInvalidMask is returned for a mask which is not contiguous.
+func ParseLoose(s string) (*net.IPNet, error) {
+	f := strings.Fields(s)
+	var addr, mask string
+	switch len(f) {
+	case 1:
+		addr, mask, _ = strings.Cut(f[0], "/")
+	case 2:
+		addr, mask = f[0], f[1]
+		if strings.Contains(addr, "/") {
+			return nil, &net.ParseError{Type: "CIDR address", Text: s}
+		}
+	default:
+		return nil, &net.ParseError{Type: "CIDR address", Text: s}
+	}
+	ip := parseLooseIP(addr)
+	if ip == nil {
+		return nil, &net.ParseError{Type: "IP address", Text: s}
+	}
+	bits := 8 * len(ip)
+	if mask == "" {
+		if strings.HasSuffix(f[0], "/") {
+			return nil, &net.ParseError{Type: "CIDR address", Text: s}
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+	if ones, ok := parsePrefixLen(mask, bits); ok {
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(ones, bits)}, nil
+	}
+	m := parseLooseIP(mask)
+	if m == nil || len(m) != len(ip) {
+		return nil, &net.ParseError{Type: "CIDR address", Text: s}
+	}
+	if _, b := net.IPMask(m).Size(); b == 0 {
+		return nil, ErrInvalidMask
+	}
+	return &net.IPNet{IP: ip, Mask: net.IPMask(m)}, nil
+}
+
+// parsePrefixLen parses a decimal prefix length of at most bits
+func parsePrefixLen(s string, bits int) (int, bool) {
+	if s == "" || len(s) > 3 || strings.Trim(s, "0123456789") != "" {
+		return 0, false
+	}
+	ones, err := strconv.Atoi(s)
+	if err != nil || ones > bits {
+		return 0, false
+	}
+	return ones, true
+}
+
+// parseLooseIP parses an IPv6 address, or an IPv4 address in any form accepted by inet_aton.
+// IPv4 addresses are returned in their 4 byte form.
+func parseLooseIP(s string) net.IP {
+	if strings.Contains(s, ":") {
+		return net.ParseIP(s)
+	}
+	parts := strings.Split(s, ".")
+	if len(parts) > net.IPv4len {
+		return nil
+	}
+	var v uint32
+	for i, p := range parts {
+		n, ok := parseAtonPart(p)
+		if !ok {
+			return nil
+		}
+		if i < len(parts)-1 {
+			if n > 0xff {
+				return nil
+			}
+			v |= uint32(n) << (8 * (3 - i))
+			continue
+		}
+		// the last part fills the remaining bytes
+		if n>>(8*(net.IPv4len-i)) != 0 {
+			return nil
+		}
+		v |= uint32(n)
+	}
+	return binary.BigEndian.AppendUint32(nil, v)
+}
+
+// parseAtonPart parses a decimal, 0x prefixed hexadecimal, or 0 prefixed octal number
+func parseAtonPart(s string) (uint64, bool) {
+	base := 10
+	switch {
+	case len(s) > 2 && (s[:2] == "0x" || s[:2] == "0X"):
+		s, base = s[2:], 16
+	case len(s) > 1 && s[0] == '0':
+		s, base = s[1:], 8
+	}
+	n, err := strconv.ParseUint(s, base, 32)
+	return n, err == nil
+}
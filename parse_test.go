@@ -0,0 +1,69 @@
+package iputil
+
+import (
+	"net"
+	"testing"
+)
+
+// nolint dupl
+func TestParseStrict(t *testing.T) {
+	for _, v := range []struct{ s, e string }{
+		{"10.1.0.1/24", "10.1.0.1/24"},
+		{"10.1.0.1", "10.1.0.1/32"},
+		{"0.0.0.0/0", "0.0.0.0/0"},
+		{"2001:db8::1/64", "2001:db8::1/64"},
+		{"2001:db8::1", "2001:db8::1/128"},
+		{"::ffff:10.1.0.1/120", "10.1.0.1/24"},
+	} {
+		n, err := ParseStrict(v.s)
+		if err != nil || n.String() != v.e {
+			t.Errorf("%v: expected %v, got %v, %v", v.s, v.e, n, err)
+		}
+	}
+	for _, s := range []string{
+		"", "10.1", "010.1.0.1", "10.1.0.1/024", "10.1.0.1/33", "10.1.0.1/", "10.1.0.1/+8",
+		"10.1.0.1/255.255.0.0", " 10.1.0.1", "10.1.0.1 255.255.0.0", "0x0a.1.0.1", "fe80::1%eth0", "2001:db8::/129",
+	} {
+		if n, err := ParseStrict(s); err == nil {
+			t.Errorf("%q: expected an error, got %v", s, n)
+		}
+	}
+}
+
+// nolint dupl
+func TestParseLoose(t *testing.T) {
+	for _, v := range []struct{ s, e string }{
+		{"10.1.0.1/24", "10.1.0.1/24"},
+		{"10.1", "10.0.0.1/32"},
+		{"10.1.2", "10.1.0.2/32"},
+		{"167772161", "10.0.0.1/32"},
+		{"0x0a.012.0.1", "10.10.0.1/32"},
+		{"0XA.0.0.0xff", "10.0.0.255/32"},
+		{"10.0.0.0 255.255.0.0", "10.0.0.0/16"},
+		{"10.0.0.5/255.255.0.0", "10.0.0.5/16"},
+		{" 10.0.0.0\t0xffffff00 ", "10.0.0.0/24"},
+		{"10.0.0.0 8", "10.0.0.0/8"},
+		{"2001:db8::1/64", "2001:db8::1/64"},
+		{"2001:db8::1 ffff:ffff::", "2001:db8::1/32"},
+	} {
+		n, err := ParseLoose(v.s)
+		if err != nil || n.String() != v.e {
+			t.Errorf("%v: expected %v, got %v, %v", v.s, v.e, n, err)
+		}
+	}
+	for _, s := range []string{
+		"", "10.1.0.1.1", "10.256.0.1", "10.1.65536", "08.0.0.1", "0x.0.0.1", "10..0.1", "10.1.0.1/",
+		"10.1.0.1/33", "10.0.0.0 255.255.0.0 1", "10.0.0.0/8 255.0.0.0", "2001:db8::1/255.255.0.0", "-1.0.0.0",
+	} {
+		if n, err := ParseLoose(s); err == nil {
+			t.Errorf("%q: expected an error, got %v", s, n)
+		}
+	}
+	if _, err := ParseLoose("10.0.0.0/255.0.255.0"); err != ErrInvalidMask {
+		t.Errorf("Expected ErrInvalidMask, got %v", err)
+	}
+	n, _ := ParseLoose("10.1.2.3/8")
+	if !n.IP.Equal(net.ParseIP("10.1.2.3")) {
+		t.Errorf("host bits should be preserved, got %v", n)
+	}
+}
@@ -0,0 +1,93 @@
+package iputil
+
+import (
+	"math/rand"
+	"net"
+)
+
+// MaxPermutationBits is the largest number of host bits a Permutation can cover, a /64 in IPv6
+const MaxPermutationBits = 64
+
+// permutationRounds is the number of Feistel rounds
+const permutationRounds = 6
+
+// Permuter yields every address in an IPNet exactly once, in a random order, without
+// storing the addresses it has visited. The order is determined by the seed.
+type Permuter struct {
+	first    net.IP
+	hostBits uint
+	half     uint
+	keys     [permutationRounds]uint64
+	i        uint64
+	done     bool
+}
+
+// Permutation returns a Permuter over the addresses of n, including the network and broadcast addresses.
+// The addresses are permuted with a Feistel cipher over the host bits, so the same seed always
+// gives the same order. ErrOverflow is returned if n has more than MaxPermutationBits host bits.
+func Permutation(n *net.IPNet, seed int64) (*Permuter, error) {
+	if err := checkNet(n); err != nil {
+		return nil, err
+	}
+	ones, bits := netSize(n)
+	hb := uint(bits - ones)
+	if hb > MaxPermutationBits {
+		return nil, ErrOverflow
+	}
+	p := &Permuter{first: FirstAddr(n), hostBits: hb, half: (hb + 1) / 2}
+	r := rand.New(rand.NewSource(seed))
+	for i := range p.keys {
+		p.keys[i] = r.Uint64()
+	}
+	return p, nil
+}
+
+// Next returns the next address, or nil when every address has been returned
+func (p *Permuter) Next() net.IP {
+	if p.done {
+		return nil
+	}
+	v := p.permute(p.i)
+	p.i++
+	if (p.hostBits < 64 && p.i == 1<<p.hostBits) || p.i == 0 {
+		p.done = true
+	}
+	ip := make(net.IP, len(p.first))
+	copy(ip, p.first)
+	for i := len(ip) - 1; i >= 0 && v != 0; i-- {
+		ip[i] |= byte(v)
+		v >>= 8
+	}
+	return ip
+}
+
+// permute maps x to its position in the permutation, cycle walking the Feistel cipher
+// until the result is within the host bits
+func (p *Permuter) permute(x uint64) uint64 {
+	if p.hostBits == 0 {
+		return 0
+	}
+	for {
+		x = p.feistel(x)
+		if p.hostBits == 64 || x < 1<<p.hostBits {
+			return x
+		}
+	}
+}
+
+// feistel is a balanced Feistel cipher over 2*p.half bits
+func (p *Permuter) feistel(x uint64) uint64 {
+	mask := uint64(1)<<p.half - 1
+	l, r := x>>p.half&mask, x&mask
+	for _, k := range p.keys {
+		l, r = r, l^(mix64(r^k)&mask)
+	}
+	return l<<p.half | r
+}
+
+// mix64 is the finalizer of splitmix64
+func mix64(x uint64) uint64 {
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
@@ -0,0 +1,126 @@
+package iputil
+
+import (
+	"net"
+	"testing"
+)
+
+// nolint dupl
+func TestPermutation(t *testing.T) {
+	_, sn, _ := net.ParseCIDR("10.1.5.0/24")
+	p, err := Permutation(sn, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	seen := map[string]bool{}
+	for ip := p.Next(); ip != nil; ip = p.Next() {
+		if !sn.Contains(ip) {
+			t.Errorf("IP %v outside subnet %v", ip, sn)
+		}
+		if seen[ip.String()] {
+			t.Errorf("IP %v returned twice", ip)
+		}
+		seen[ip.String()] = true
+	}
+	if len(seen) != 256 {
+		t.Errorf("expected 256 addresses, got %v", len(seen))
+	}
+	if p.Next() != nil {
+		t.Errorf("Next should keep returning nil when done")
+	}
+}
+
+// nolint dupl
+func TestPermutationSeed(t *testing.T) {
+	_, sn, _ := net.ParseCIDR("10.1.0.0/16")
+	p1, _ := Permutation(sn, 1)
+	p2, _ := Permutation(sn, 1)
+	p3, _ := Permutation(sn, 2)
+	same, diff := true, false
+	for i := 0; i < 100; i++ {
+		a, b, c := p1.Next(), p2.Next(), p3.Next()
+		same = same && a.Equal(b)
+		diff = diff || !a.Equal(c)
+	}
+	if !same {
+		t.Errorf("permutations with the same seed should be equal")
+	}
+	if !diff {
+		t.Errorf("permutations with different seeds should differ")
+	}
+}
+
+// nolint dupl
+func TestPermutationOddBits(t *testing.T) {
+	_, sn, _ := net.ParseCIDR("10.1.0.0/21")
+	p, _ := Permutation(sn, 5)
+	seen := map[string]bool{}
+	for ip := p.Next(); ip != nil; ip = p.Next() {
+		if !sn.Contains(ip) || seen[ip.String()] {
+			t.Fatalf("IP %v outside subnet %v or returned twice", ip, sn)
+		}
+		seen[ip.String()] = true
+	}
+	if len(seen) != 2048 {
+		t.Errorf("expected 2048 addresses, got %v", len(seen))
+	}
+}
+
+// nolint dupl
+func TestPermutationSlash8(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping /8 permutation in short mode")
+	}
+	_, sn, _ := net.ParseCIDR("10.0.0.0/8")
+	p, _ := Permutation(sn, 8)
+	seen := make([]uint64, 1<<24/64)
+	c := 0
+	for ip := p.Next(); ip != nil; ip = p.Next() {
+		i := int(ip[1])<<16 | int(ip[2])<<8 | int(ip[3])
+		if seen[i/64]&(1<<uint(i%64)) != 0 {
+			t.Fatalf("IP %v returned twice", ip)
+		}
+		seen[i/64] |= 1 << uint(i%64)
+		c++
+	}
+	if c != 1<<24 {
+		t.Errorf("expected %v addresses, got %v", 1<<24, c)
+	}
+}
+
+// nolint dupl
+func TestPermutation6(t *testing.T) {
+	_, sn, _ := net.ParseCIDR("fe80::/64")
+	p, err := Permutation(sn, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	seen := map[string]bool{}
+	for i := 0; i < 1000; i++ {
+		ip := p.Next()
+		if !sn.Contains(ip) || seen[ip.String()] {
+			t.Fatalf("IP %v outside subnet %v or returned twice", ip, sn)
+		}
+		seen[ip.String()] = true
+	}
+}
+
+// nolint dupl
+func TestPermutationTooLarge6(t *testing.T) {
+	_, sn, _ := net.ParseCIDR("fe80::/63")
+	if _, err := Permutation(sn, 1); err != ErrOverflow {
+		t.Errorf("Expected ErrOverflow, got %v", err)
+	}
+}
+
+// nolint dupl
+func TestPermutationSingle(t *testing.T) {
+	_, sn, _ := net.ParseCIDR("fe80::1/128")
+	p, _ := Permutation(sn, 1)
+	if ip := p.Next(); !ip.Equal(net.ParseIP("fe80::1")) {
+		t.Errorf("%v should equal fe80::1", ip)
+	}
+	if ip := p.Next(); ip != nil {
+		t.Errorf("expected nil, got %v", ip)
+	}
+}
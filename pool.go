@@ -0,0 +1,192 @@
+package iputil
+
+import (
+	"net"
+	"sync"
+)
+
+// AddressPool is implemented by the pools which allocate individual addresses, Pool and BitmapPool
+type AddressPool interface {
+	// Allocate allocates a free address
+	Allocate() (net.IP, error)
+	// AllocateIP allocates a specific address
+	AllocateIP(ip net.IP) error
+	// Release frees an allocated address
+	Release(ip net.IP) error
+	// Allocated returns true if ip is allocated
+	Allocated(ip net.IP) bool
+	// Size returns the number of addresses in the pool
+	Size() int
+	// Free returns the number of addresses which are not allocated
+	Free() int
+	// SetExclude sets a function which excludes addresses from Allocate
+	SetExclude(exclude ExcludeFunc)
+	// SetStrategy sets the Strategy used by Allocate to choose addresses
+	SetStrategy(s Strategy)
+}
+
+// Pool allocates addresses from an IPNet. It is safe for concurrent use.
+type Pool struct {
+	mu       sync.Mutex
+	first    net.IP
+	size     int
+	used     map[int]struct{} // offsets from first
+	exclude  ExcludeFunc
+	strategy Strategy
+}
+
+var _ AddressPool = (*Pool)(nil)
+
+// NewPool returns a Pool of the addresses in n, excluding the first xf and last xl addresses.
+// To exclude the network and broadcast addresses use 1 for xf and xl. ErrExhausted is returned
+// if the exclusions leave no addresses, and ErrOverflow if the pool is too large to count in an int.
+func NewPool(n *net.IPNet, xf, xl int) (*Pool, error) {
+	f, size, err := poolRange(n, xf, xl)
+	if err != nil {
+		return nil, err
+	}
+	return &Pool{first: f, size: size, used: make(map[int]struct{}), strategy: RandomStrategy()}, nil
+}
+
+// poolRange returns the first address and number of addresses in a pool of n, excluding
+// the first xf and last xl addresses
+func poolRange(n *net.IPNet, xf, xl int) (net.IP, int, error) {
+	if err := checkNet(n); err != nil {
+		return nil, 0, err
+	}
+	if xf < 0 || xl < 0 {
+		return nil, 0, ErrOverflow
+	}
+	f, err := IPAddChecked(FirstAddr(n), xf)
+	if err != nil {
+		return nil, 0, ErrExhausted
+	}
+	l, err := IPAddChecked(LastAddr(n), -xl)
+	if err != nil || IPBefore(l, f) {
+		return nil, 0, ErrExhausted
+	}
+	d, err := IPDiffChecked(l, f)
+	if err != nil || d == int(^uint(0)>>1) {
+		return nil, 0, ErrOverflow
+	}
+	return f, d + 1, nil
+}
+
+// SetExclude sets a function called for each address before it is allocated by Allocate.
+// Excluded addresses are skipped, but remain free. Neighbors.Occupied can be used to skip
+// addresses already in use on the network.
+func (p *Pool) SetExclude(exclude ExcludeFunc) {
+	p.mu.Lock()
+	p.exclude = exclude
+	p.mu.Unlock()
+}
+
+// SetStrategy sets the Strategy used by Allocate to choose addresses. Addresses which are
+// already allocated are passed to the strategy's Allocated method.
+func (p *Pool) SetStrategy(s Strategy) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for o := range p.used {
+		s.Allocated(o)
+	}
+	p.strategy = s
+}
+
+// Size returns the number of addresses in the pool
+func (p *Pool) Size() int {
+	return p.size
+}
+
+// Free returns the number of addresses which are not allocated
+func (p *Pool) Free() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.size - len(p.used)
+}
+
+// Allocate allocates a free address chosen by the pool's Strategy. ErrExhausted is returned if there are no free addresses.
+func (p *Pool) Allocate() (net.IP, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.used) >= p.size {
+		return nil, ErrExhausted
+	}
+	o, ok := p.strategy.Select(p.size, p.free)
+	if !ok {
+		return nil, ErrExhausted
+	}
+	p.used[o] = struct{}{}
+	p.strategy.Allocated(o)
+	return IPAdd(p.first, o), nil
+}
+
+// free returns true if offset o is not allocated or excluded
+func (p *Pool) free(o int) bool {
+	if _, ok := p.used[o]; ok {
+		return false
+	}
+	return p.exclude == nil || !p.exclude(IPAdd(p.first, o))
+}
+
+// AllocateIP allocates a specific address. ErrNotInPool or ErrInUse are returned
+// if it is outside of the pool or already allocated.
+func (p *Pool) AllocateIP(ip net.IP) error {
+	o, err := p.offset(ip)
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.used[o]; ok {
+		return ErrInUse
+	}
+	p.used[o] = struct{}{}
+	p.strategy.Allocated(o)
+	return nil
+}
+
+// Release frees an allocated address. ErrNotInPool or ErrNotAllocated are returned
+// if it is outside of the pool or not allocated.
+func (p *Pool) Release(ip net.IP) error {
+	o, err := p.offset(ip)
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.used[o]; !ok {
+		return ErrNotAllocated
+	}
+	delete(p.used, o)
+	p.strategy.Released(o)
+	return nil
+}
+
+// Allocated returns true if ip is allocated
+func (p *Pool) Allocated(ip net.IP) bool {
+	o, err := p.offset(ip)
+	if err != nil {
+		return false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, ok := p.used[o]
+	return ok
+}
+
+// offset returns the offset of ip from the first address of the pool
+func (p *Pool) offset(ip net.IP) (int, error) {
+	return poolOffset(p.first, p.size, ip)
+}
+
+// poolOffset returns the offset of ip from first, in a pool of size addresses
+func poolOffset(first net.IP, size int, ip net.IP) (int, error) {
+	if ip == nil || checkFamily(ip, first) != nil {
+		return 0, ErrNotInPool
+	}
+	o, err := IPDiffChecked(ip, first)
+	if err != nil || o < 0 || o >= size {
+		return 0, ErrNotInPool
+	}
+	return o, nil
+}
@@ -0,0 +1,99 @@
+package iputil
+
+import (
+	"net"
+	"testing"
+)
+
+// nolint dupl
+func TestPoolAllocate(t *testing.T) {
+	_, sn, _ := net.ParseCIDR("10.1.0.0/29")
+	p, err := NewPool(sn, 1, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.Size() != 6 {
+		t.Errorf("expected 6 addresses, got %v", p.Size())
+	}
+	seen := map[string]bool{}
+	for i := 0; i < 6; i++ {
+		ip, err := p.Allocate()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if ip.Equal(net.ParseIP("10.1.0.0")) || ip.Equal(net.ParseIP("10.1.0.7")) || seen[ip.String()] {
+			t.Errorf("unexpected address %v", ip)
+		}
+		seen[ip.String()] = true
+	}
+	if _, err := p.Allocate(); err != ErrExhausted {
+		t.Errorf("Expected ErrExhausted, got %v", err)
+	}
+	if p.Free() != 0 {
+		t.Errorf("expected no free addresses, got %v", p.Free())
+	}
+}
+
+// nolint dupl
+func TestPoolAllocateIP(t *testing.T) {
+	_, sn, _ := net.ParseCIDR("10.1.0.0/29")
+	p, _ := NewPool(sn, 1, 1)
+	ip := net.ParseIP("10.1.0.3")
+	if err := p.AllocateIP(ip); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := p.AllocateIP(ip); err != ErrInUse {
+		t.Errorf("Expected ErrInUse, got %v", err)
+	}
+	if !p.Allocated(ip) {
+		t.Errorf("%v should be allocated", ip)
+	}
+	for _, s := range []string{"10.1.0.0", "10.1.0.7", "10.2.0.1", "fe80::1"} {
+		if err := p.AllocateIP(net.ParseIP(s)); err != ErrNotInPool {
+			t.Errorf("%v: Expected ErrNotInPool, got %v", s, err)
+		}
+	}
+}
+
+// nolint dupl
+func TestPoolRelease(t *testing.T) {
+	_, sn, _ := net.ParseCIDR("10.1.0.0/30")
+	p, _ := NewPool(sn, 0, 0)
+	ip, _ := p.Allocate()
+	if err := p.Release(ip); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := p.Release(ip); err != ErrNotAllocated {
+		t.Errorf("Expected ErrNotAllocated, got %v", err)
+	}
+	if p.Free() != 4 {
+		t.Errorf("expected 4 free addresses, got %v", p.Free())
+	}
+}
+
+// nolint dupl
+func TestPoolExclude(t *testing.T) {
+	_, sn, _ := net.ParseCIDR("10.1.0.0/30")
+	p, _ := NewPool(sn, 0, 0)
+	free := net.ParseIP("10.1.0.2")
+	p.SetExclude(func(ip net.IP) bool { return !ip.Equal(free) })
+	ip, err := p.Allocate()
+	if err != nil || !ip.Equal(free) {
+		t.Errorf("expected %v, got %v, %v", free, ip, err)
+	}
+	if _, err := p.Allocate(); err != ErrExhausted {
+		t.Errorf("Expected ErrExhausted, got %v", err)
+	}
+}
+
+// nolint dupl
+func TestNewPoolBad(t *testing.T) {
+	_, sn, _ := net.ParseCIDR("10.1.0.0/30")
+	if _, err := NewPool(sn, 2, 2); err != ErrExhausted {
+		t.Errorf("Expected ErrExhausted, got %v", err)
+	}
+	_, sn6, _ := net.ParseCIDR("fe80::/64")
+	if _, err := NewPool(sn6, 0, 0); err != ErrOverflow {
+		t.Errorf("Expected ErrOverflow, got %v", err)
+	}
+}
@@ -0,0 +1,256 @@
+package iputil
+
+import (
+	"container/list"
+	"math/big"
+	"net"
+	"sync"
+)
+
+// PrefixPool delegates child prefixes of a fixed length from one or more parent prefixes,
+// such as /56s from a /40 for IPv6 prefix delegation. Each prefix is assigned to a client ID.
+// It is safe for concurrent use.
+type PrefixPool struct {
+	mu       sync.Mutex
+	bits     int
+	parents  []*prefixParent
+	clients  map[string]*net.IPNet
+	used     map[string]string // prefix to client ID
+	released *list.List        // free prefixes which have been assigned before, oldest release first
+	elems    map[string]*list.Element
+	// sticky claims of clients to the prefix they last released, oldest release first.
+	// A claim is dropped when its prefix is assigned to anyone.
+	sticky         *list.List
+	stickyByClient map[string]*list.Element
+	stickyByPrefix map[string]*list.Element
+	size           *big.Int
+}
+
+// maxSticky is the most released prefixes a PrefixPool remembers the previous client of
+const maxSticky = 1 << 16
+
+type stickyClaim struct {
+	id     string
+	prefix *net.IPNet
+}
+
+type prefixParent struct {
+	n     *net.IPNet
+	count *big.Int // number of child prefixes
+	next  *big.Int // index of the next never allocated child prefix
+}
+
+// NewPrefixPool returns a PrefixPool delegating prefixes of length bits from parents.
+// ErrInvalidMask is returned if bits is shorter than a parent prefix or longer than its
+// addresses, ErrFamilyMismatch if the parents are not all the same family, and ErrExhausted
+// if there are no parents.
+func NewPrefixPool(bits int, parents ...*net.IPNet) (*PrefixPool, error) {
+	if len(parents) == 0 {
+		return nil, ErrExhausted
+	}
+	pp := &PrefixPool{
+		bits:           bits,
+		clients:        make(map[string]*net.IPNet),
+		used:           make(map[string]string),
+		released:       list.New(),
+		elems:          make(map[string]*list.Element),
+		sticky:         list.New(),
+		stickyByClient: make(map[string]*list.Element),
+		stickyByPrefix: make(map[string]*list.Element),
+		size:           new(big.Int),
+	}
+	for _, p := range parents {
+		if err := checkNet(p); err != nil {
+			return nil, err
+		}
+		c := canonicalNet(p)
+		ones, width := netSize(c)
+		if bits < ones || bits > width {
+			return nil, ErrInvalidMask
+		}
+		if len(pp.parents) > 0 && len(c.IP) != len(pp.parents[0].n.IP) {
+			return nil, ErrFamilyMismatch
+		}
+		count := hostCount(ones, bits)
+		pp.parents = append(pp.parents, &prefixParent{n: c, count: count, next: new(big.Int)})
+		pp.size.Add(pp.size, count)
+	}
+	return pp, nil
+}
+
+// Allocate returns the prefix assigned to id, assigning one if it has none. A client which
+// released its prefix is given the same one back if it has not been reassigned, and was
+// among the last 65536 prefixes released. Otherwise
+// prefixes which have never been assigned are used before released ones, so released
+// prefixes stay available to their previous client for as long as possible.
+// ErrExhausted is returned if there are no free prefixes.
+func (pp *PrefixPool) Allocate(id string) (*net.IPNet, error) {
+	pp.mu.Lock()
+	defer pp.mu.Unlock()
+	if n, ok := pp.clients[id]; ok {
+		return copyNet(n), nil
+	}
+	if e, ok := pp.stickyByClient[id]; ok {
+		n := e.Value.(stickyClaim).prefix
+		pp.assign(id, n)
+		return copyNet(n), nil
+	}
+	for _, p := range pp.parents {
+		for p.next.Cmp(p.count) < 0 {
+			n := pp.child(p, p.next)
+			p.next.Add(p.next, big.NewInt(1))
+			if _, used := pp.used[n.String()]; !used {
+				pp.assign(id, n)
+				return copyNet(n), nil
+			}
+		}
+	}
+	if e := pp.released.Front(); e != nil {
+		n := e.Value.(*net.IPNet)
+		pp.assign(id, n)
+		return copyNet(n), nil
+	}
+	return nil, ErrExhausted
+}
+
+// AllocatePrefix assigns a specific prefix to id, replacing any prefix it already holds.
+// ErrNotInPool is returned if n is not a child prefix of a parent, and ErrInUse if it is
+// assigned to another client.
+func (pp *PrefixPool) AllocatePrefix(id string, n *net.IPNet) error {
+	c := canonicalNet(n)
+	if c == nil || !pp.contains(c) {
+		return ErrNotInPool
+	}
+	pp.mu.Lock()
+	defer pp.mu.Unlock()
+	if owner, ok := pp.used[c.String()]; ok {
+		if owner == id {
+			return nil
+		}
+		return ErrInUse
+	}
+	if old, ok := pp.clients[id]; ok {
+		pp.release(id, old)
+	}
+	pp.assign(id, c)
+	return nil
+}
+
+// Release frees the prefix assigned to id. ErrNotAllocated is returned if it has none.
+func (pp *PrefixPool) Release(id string) error {
+	pp.mu.Lock()
+	defer pp.mu.Unlock()
+	n, ok := pp.clients[id]
+	if !ok {
+		return ErrNotAllocated
+	}
+	pp.release(id, n)
+	return nil
+}
+
+// Get returns the prefix assigned to id
+func (pp *PrefixPool) Get(id string) (*net.IPNet, bool) {
+	pp.mu.Lock()
+	defer pp.mu.Unlock()
+	n, ok := pp.clients[id]
+	if !ok {
+		return nil, false
+	}
+	return copyNet(n), true
+}
+
+// Owner returns the client ID a prefix is assigned to
+func (pp *PrefixPool) Owner(n *net.IPNet) (string, bool) {
+	c := canonicalNet(n)
+	if c == nil {
+		return "", false
+	}
+	pp.mu.Lock()
+	defer pp.mu.Unlock()
+	id, ok := pp.used[c.String()]
+	return id, ok
+}
+
+// Size returns the total number of child prefixes in the pool
+func (pp *PrefixPool) Size() *big.Int {
+	return new(big.Int).Set(pp.size)
+}
+
+// Allocated returns the number of assigned prefixes
+func (pp *PrefixPool) Allocated() *big.Int {
+	pp.mu.Lock()
+	defer pp.mu.Unlock()
+	return big.NewInt(int64(len(pp.used)))
+}
+
+// Free returns the number of unassigned prefixes
+func (pp *PrefixPool) Free() *big.Int {
+	return new(big.Int).Sub(pp.Size(), pp.Allocated())
+}
+
+// Utilization returns the fraction of prefixes which are assigned, between 0 and 1
+func (pp *PrefixPool) Utilization() float64 {
+	u, _ := new(big.Rat).SetFrac(pp.Allocated(), pp.size).Float64()
+	return u
+}
+
+func (pp *PrefixPool) assign(id string, n *net.IPNet) {
+	pp.clients[id] = n
+	pp.used[n.String()] = id
+	if e, ok := pp.stickyByClient[id]; ok {
+		pp.dropSticky(e)
+	}
+	if e, ok := pp.stickyByPrefix[n.String()]; ok {
+		pp.dropSticky(e)
+	}
+	if e, ok := pp.elems[n.String()]; ok {
+		pp.released.Remove(e)
+		delete(pp.elems, n.String())
+	}
+}
+
+func (pp *PrefixPool) release(id string, n *net.IPNet) {
+	delete(pp.clients, id)
+	delete(pp.used, n.String())
+	pp.elems[n.String()] = pp.released.PushBack(n)
+	if e, ok := pp.stickyByClient[id]; ok {
+		pp.dropSticky(e)
+	}
+	e := pp.sticky.PushBack(stickyClaim{id: id, prefix: n})
+	pp.stickyByClient[id] = e
+	pp.stickyByPrefix[n.String()] = e
+	if pp.sticky.Len() > maxSticky {
+		pp.dropSticky(pp.sticky.Front())
+	}
+}
+
+func (pp *PrefixPool) dropSticky(e *list.Element) {
+	c := pp.sticky.Remove(e).(stickyClaim)
+	delete(pp.stickyByClient, c.id)
+	delete(pp.stickyByPrefix, c.prefix.String())
+}
+
+// child returns the i'th child prefix of p
+func (pp *PrefixPool) child(p *prefixParent, i *big.Int) *net.IPNet {
+	_, width := netSize(p.n)
+	off := new(big.Int).Lsh(i, uint(width-pp.bits))
+	ip := intToIP(off.Add(off, ipToInt(p.n.IP)), p.n.IP)
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(pp.bits, width)}
+}
+
+// contains returns true if canonical prefix c is a child prefix in the pool
+func (pp *PrefixPool) contains(c *net.IPNet) bool {
+	if ones, _ := netSize(c); ones != pp.bits {
+		return false
+	}
+	for _, p := range pp.parents {
+		if len(p.n.IP) == len(c.IP) && p.n.Contains(c.IP) {
+			return true
+		}
+	}
+	return false
+}
+
+func copyNet(n *net.IPNet) *net.IPNet {
+	return &net.IPNet{IP: append(net.IP(nil), n.IP...), Mask: append(net.IPMask(nil), n.Mask...)}
+}
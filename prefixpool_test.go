@@ -0,0 +1,198 @@
+package iputil
+
+import (
+	"math/big"
+	"net"
+	"strconv"
+	"testing"
+)
+
+func testPrefixPool(t *testing.T, bits int, parents ...string) *PrefixPool {
+	var nets []*net.IPNet
+	for _, p := range parents {
+		_, n, _ := net.ParseCIDR(p)
+		nets = append(nets, n)
+	}
+	pp, err := NewPrefixPool(bits, nets...)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return pp
+}
+
+// nolint dupl
+func TestPrefixPoolAllocate(t *testing.T) {
+	pp := testPrefixPool(t, 56, "2001:db8::/55", "2001:db8:1::/56")
+	for i, e := range []string{"2001:db8::/56", "2001:db8:0:100::/56", "2001:db8:1::/56"} {
+		n, err := pp.Allocate(string(rune('a' + i)))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if n.String() != e {
+			t.Errorf("expected %v, got %v", e, n)
+		}
+	}
+	if n, _ := pp.Allocate("b"); n.String() != "2001:db8:0:100::/56" {
+		t.Errorf("expected b to keep its prefix, got %v", n)
+	}
+	if _, err := pp.Allocate("d"); err != ErrExhausted {
+		t.Errorf("Expected ErrExhausted, got %v", err)
+	}
+	if id, ok := pp.Owner(mustCIDR("2001:db8:1::/56")); !ok || id != "c" {
+		t.Errorf("expected owner c, got %v", id)
+	}
+}
+
+// nolint dupl
+func TestPrefixPoolSticky(t *testing.T) {
+	pp := testPrefixPool(t, 60, "2001:db8::/58")
+	a, _ := pp.Allocate("a")
+	_, _ = pp.Allocate("b")
+	if err := pp.Release("a"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := pp.Release("a"); err != ErrNotAllocated {
+		t.Errorf("Expected ErrNotAllocated, got %v", err)
+	}
+	c, _ := pp.Allocate("c")
+	if c.String() == a.String() {
+		t.Errorf("unused prefixes should be assigned before released ones, got %v", c)
+	}
+	if a2, _ := pp.Allocate("a"); a2.String() != a.String() {
+		t.Errorf("expected a to get %v back, got %v", a, a2)
+	}
+}
+
+// nolint dupl
+func TestPrefixPoolReuse(t *testing.T) {
+	pp := testPrefixPool(t, 64, "2001:db8::/63")
+	a, _ := pp.Allocate("a")
+	_, _ = pp.Allocate("b")
+	_ = pp.Release("a")
+	c, err := pp.Allocate("c")
+	if err != nil || c.String() != a.String() {
+		t.Errorf("expected %v to be reused, got %v, %v", a, c, err)
+	}
+	if a2, _ := pp.Allocate("a"); a2 != nil {
+		t.Errorf("expected pool to be exhausted, got %v", a2)
+	}
+}
+
+// nolint dupl
+func TestPrefixPoolAllocatePrefix(t *testing.T) {
+	pp := testPrefixPool(t, 56, "2001:db8::/54")
+	if err := pp.AllocatePrefix("a", mustCIDR("2001:db8::/56")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := pp.AllocatePrefix("b", mustCIDR("2001:db8::/56")); err != ErrInUse {
+		t.Errorf("Expected ErrInUse, got %v", err)
+	}
+	for _, n := range []string{"2001:db8::/57", "2001:db9::/56", "10.0.0.0/24"} {
+		if err := pp.AllocatePrefix("b", mustCIDR(n)); err != ErrNotInPool {
+			t.Errorf("%v: Expected ErrNotInPool, got %v", n, err)
+		}
+	}
+	if n, _ := pp.Allocate("b"); n.String() != "2001:db8:0:100::/56" {
+		t.Errorf("expected static prefix to be skipped, got %v", n)
+	}
+}
+
+// nolint dupl
+func TestPrefixPoolUtilization(t *testing.T) {
+	pp := testPrefixPool(t, 64, "2001:db8::/32")
+	e, _ := new(big.Int).SetString("4294967296", 10)
+	if pp.Size().Cmp(e) != 0 {
+		t.Errorf("expected size %v, got %v", e, pp.Size())
+	}
+	for _, id := range []string{"a", "b"} {
+		_, _ = pp.Allocate(id)
+	}
+	if pp.Allocated().Int64() != 2 {
+		t.Errorf("expected 2 allocated, got %v", pp.Allocated())
+	}
+	if pp.Free().Cmp(e.Sub(e, big.NewInt(2))) != 0 {
+		t.Errorf("expected %v free, got %v", e, pp.Free())
+	}
+	if u := pp.Utilization(); u != 2.0/4294967296 {
+		t.Errorf("unexpected utilization %v", u)
+	}
+}
+
+// nolint dupl
+func TestNewPrefixPoolBad(t *testing.T) {
+	if _, err := NewPrefixPool(40, mustCIDR("2001:db8::/48")); err != ErrInvalidMask {
+		t.Errorf("Expected ErrInvalidMask, got %v", err)
+	}
+	if _, err := NewPrefixPool(129, mustCIDR("2001:db8::/48")); err != ErrInvalidMask {
+		t.Errorf("Expected ErrInvalidMask, got %v", err)
+	}
+	if _, err := NewPrefixPool(28, mustCIDR("2001:db8::/24"), mustCIDR("10.0.0.0/8")); err != ErrFamilyMismatch {
+		t.Errorf("Expected ErrFamilyMismatch, got %v", err)
+	}
+	if _, err := NewPrefixPool(64); err != ErrExhausted {
+		t.Errorf("Expected ErrExhausted, got %v", err)
+	}
+}
+
+func mustCIDR(s string) *net.IPNet {
+	_, n, err := net.ParseCIDR(s)
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
+
+// nolint dupl
+func TestPrefixPoolStickyCycle(t *testing.T) {
+	pp := testPrefixPool(t, 64, "2001:db8::/62")
+	for i := 0; i < 1000; i++ {
+		_, _ = pp.Allocate("a")
+		_ = pp.Release("a")
+	}
+	if l := pp.released.Len(); l != 1 {
+		t.Errorf("expected 1 released prefix, got %v", l)
+	}
+	_, _ = pp.Allocate("a")
+	if l := pp.released.Len(); l != 0 {
+		t.Errorf("expected no released prefixes, got %v", l)
+	}
+}
+
+// nolint dupl
+func TestPrefixPoolStickyReassigned(t *testing.T) {
+	pp := testPrefixPool(t, 64, "2001:db8::/63")
+	_, _ = pp.Allocate("a")
+	p, _ := pp.Allocate("b")
+	_ = pp.Release("b")
+	_ = pp.AllocatePrefix("c", p)
+	if _, ok := pp.stickyByClient["b"]; ok {
+		t.Errorf("b's claim on %v should have been dropped when it was reassigned", p)
+	}
+	_ = pp.Release("c")
+	_ = pp.Release("a")
+	if n, _ := pp.Allocate("c"); n.String() != p.String() {
+		t.Errorf("expected c, the most recent holder, to get %v back, got %v", p, n)
+	}
+	if n, _ := pp.Allocate("b"); n.String() == p.String() {
+		t.Errorf("b should not get %v back", p)
+	}
+	if len(pp.stickyByClient) != 0 || len(pp.stickyByPrefix) != 0 || pp.sticky.Len() != 0 {
+		t.Errorf("expected no sticky claims once every prefix is reassigned, got %v", pp.sticky.Len())
+	}
+}
+
+// nolint dupl
+func TestPrefixPoolStickyLimit(t *testing.T) {
+	pp := testPrefixPool(t, 64, "2001:db8::/40")
+	for i := 0; i < maxSticky+10; i++ {
+		id := strconv.Itoa(i)
+		_, _ = pp.Allocate(id)
+		_ = pp.Release(id)
+	}
+	if l := len(pp.stickyByClient); l != maxSticky {
+		t.Errorf("expected %v sticky claims, got %v", maxSticky, l)
+	}
+	if _, ok := pp.stickyByClient["0"]; ok {
+		t.Errorf("expected the oldest claim to be dropped")
+	}
+}
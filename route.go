@@ -0,0 +1,174 @@
+package iputil
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// route flags from linux/route.h
+const (
+	routeUp      = 0x1
+	routeGateway = 0x2
+	routeReject  = 0x200
+)
+
+// Route is an entry in the kernel routing table
+type Route struct {
+	Dst     *net.IPNet
+	Gateway net.IP // Gateway is nil for directly connected routes
+	Iface   string
+	Metric  int
+	Flags   int
+}
+
+// Usable returns true if the route is up and does not reject traffic
+func (r Route) Usable() bool {
+	return r.Flags&routeUp != 0 && r.Flags&routeReject == 0
+}
+
+// RouteTable is a list of routes
+type RouteTable []Route
+
+// Lookup returns the route used to reach ip, the usable route with the longest matching
+// prefix, with ties broken by the lowest metric
+func (t RouteTable) Lookup(ip net.IP) (Route, bool) {
+	var best Route
+	bestOnes := -1
+	for _, r := range t {
+		if !r.Usable() || checkFamily(r.Dst.IP, ip) != nil || !r.Dst.Contains(ip) {
+			continue
+		}
+		ones, _ := r.Dst.Mask.Size()
+		if ones > bestOnes || (ones == bestOnes && r.Metric < best.Metric) {
+			best, bestOnes = r, ones
+		}
+	}
+	return best, bestOnes >= 0
+}
+
+// ParseIPv4Routes parses routes in the format of /proc/net/route, where addresses
+// are hexadecimal in host byte order
+func ParseIPv4Routes(r io.Reader) (RouteTable, error) {
+	var t RouteTable
+	err := parseRouteLines(r, true, func(f []string) error {
+		if len(f) < 8 {
+			return fmt.Errorf("expected at least 8 fields, got %v", len(f))
+		}
+		dst, err := parseHostOrderIPv4(f[1])
+		if err != nil {
+			return err
+		}
+		gw, err := parseHostOrderIPv4(f[2])
+		if err != nil {
+			return err
+		}
+		flags, err := strconv.ParseInt(f[3], 16, 0)
+		if err != nil {
+			return err
+		}
+		metric, err := strconv.Atoi(f[6])
+		if err != nil {
+			return err
+		}
+		mask, err := parseHostOrderIPv4(f[7])
+		if err != nil {
+			return err
+		}
+		rt := Route{
+			Dst:    &net.IPNet{IP: dst, Mask: net.IPMask(mask)},
+			Iface:  f[0],
+			Metric: metric,
+			Flags:  int(flags),
+		}
+		if flags&routeGateway != 0 {
+			rt.Gateway = gw
+		}
+		t = append(t, rt)
+		return nil
+	})
+	return t, err
+}
+
+// ParseIPv6Routes parses routes in the format of /proc/net/ipv6_route
+func ParseIPv6Routes(r io.Reader) (RouteTable, error) {
+	var t RouteTable
+	err := parseRouteLines(r, false, func(f []string) error {
+		if len(f) < 10 {
+			return fmt.Errorf("expected 10 fields, got %v", len(f))
+		}
+		dst, err := parseHexIPv6(f[0])
+		if err != nil {
+			return err
+		}
+		ones, err := strconv.ParseUint(f[1], 16, 8)
+		if err != nil || ones > 128 {
+			return fmt.Errorf("invalid prefix length %q", f[1])
+		}
+		gw, err := parseHexIPv6(f[4])
+		if err != nil {
+			return err
+		}
+		metric, err := strconv.ParseUint(f[5], 16, 32)
+		if err != nil {
+			return err
+		}
+		flags, err := strconv.ParseUint(f[8], 16, 32)
+		if err != nil {
+			return err
+		}
+		rt := Route{
+			Dst:    &net.IPNet{IP: dst, Mask: net.CIDRMask(int(ones), 128)},
+			Iface:  f[9],
+			Metric: int(metric),
+			Flags:  int(flags),
+		}
+		if flags&routeGateway != 0 {
+			rt.Gateway = gw
+		}
+		t = append(t, rt)
+		return nil
+	})
+	return t, err
+}
+
+// parseRouteLines calls f with the fields of each non-empty line, skipping the first if header is true
+func parseRouteLines(r io.Reader, header bool, f func([]string) error) error {
+	s := bufio.NewScanner(r)
+	for line := 1; s.Scan(); line++ {
+		if line == 1 && header {
+			continue
+		}
+		fields := strings.Fields(s.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if err := f(fields); err != nil {
+			return fmt.Errorf("iputil: route line %v: %w", line, err)
+		}
+	}
+	return s.Err()
+}
+
+func parseHostOrderIPv4(s string) (net.IP, error) {
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return nil, err
+	}
+	ip := make(net.IP, net.IPv4len)
+	binary.NativeEndian.PutUint32(ip, uint32(v))
+	return ip, nil
+}
+
+func parseHexIPv6(s string) (net.IP, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != net.IPv6len {
+		return nil, fmt.Errorf("invalid IPv6 address %q", s)
+	}
+	return net.IP(b), nil
+}
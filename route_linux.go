@@ -0,0 +1,29 @@
+package iputil
+
+import (
+	"io"
+	"os"
+)
+
+// ReadRoutes reads the IPv4 and IPv6 kernel routing tables from /proc/net/route
+// and /proc/net/ipv6_route. The IPv6 table is skipped if IPv6 is disabled.
+func ReadRoutes() (RouteTable, error) {
+	t, err := readRouteFile("/proc/net/route", ParseIPv4Routes)
+	if err != nil {
+		return nil, err
+	}
+	t6, err := readRouteFile("/proc/net/ipv6_route", ParseIPv6Routes)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return append(t, t6...), nil
+}
+
+func readRouteFile(path string, parse func(io.Reader) (RouteTable, error)) (RouteTable, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parse(f)
+}
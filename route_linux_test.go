@@ -0,0 +1,16 @@
+package iputil
+
+import (
+	"os"
+	"testing"
+)
+
+// nolint dupl
+func TestReadRoutes(t *testing.T) {
+	if _, err := os.Stat("/proc/net/route"); err != nil {
+		t.Skip("no /proc/net/route")
+	}
+	if _, err := ReadRoutes(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+package iputil
+
+import (
+	"net"
+	"os"
+	"strings"
+	"testing"
+)
+
+func testRoutes(t *testing.T) RouteTable {
+	f, err := os.Open("testdata/route")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	rt, err := ParseIPv4Routes(f)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f6, err := os.Open("testdata/ipv6_route")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f6.Close()
+	rt6, err := ParseIPv6Routes(f6)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return append(rt, rt6...)
+}
+
+// nolint dupl
+func TestParseIPv4Routes(t *testing.T) {
+	rt := testRoutes(t)
+	if len(rt) != 12 {
+		t.Fatalf("expected 12 routes, got %v", len(rt))
+	}
+	r := rt[0]
+	if r.Dst.String() != "0.0.0.0/0" || !r.Gateway.Equal(net.ParseIP("192.168.1.1")) || r.Iface != "eth0" || r.Metric != 100 {
+		t.Errorf("unexpected default route %+v", r)
+	}
+	r = rt[2]
+	if r.Dst.String() != "192.168.1.0/24" || r.Gateway != nil {
+		t.Errorf("unexpected connected route %+v", r)
+	}
+}
+
+// nolint dupl
+func TestParseIPv6Routes(t *testing.T) {
+	rt := testRoutes(t)[7:]
+	r := rt[0]
+	if r.Dst.String() != "2001:db8::/64" || r.Gateway != nil || r.Metric != 256 || r.Iface != "eth0" {
+		t.Errorf("unexpected connected route %+v", r)
+	}
+	r = rt[2]
+	if r.Dst.String() != "::/0" || !r.Gateway.Equal(net.ParseIP("fe80::1")) || r.Metric != 1024 {
+		t.Errorf("unexpected default route %+v", r)
+	}
+}
+
+// nolint dupl
+func TestRouteLookup(t *testing.T) {
+	rt := testRoutes(t)
+	for _, tc := range []struct {
+		ip, iface, gw string
+	}{
+		{"8.8.8.8", "eth0", "192.168.1.1"},
+		{"192.168.1.20", "eth0", "<nil>"},
+		{"172.16.5.5", "eth1", "<nil>"},
+		{"10.1.2.3", "eth1", "172.16.0.254"},
+		{"10.2.0.1", "eth1", "172.16.0.254"},
+		{"2001:db8::5", "eth0", "<nil>"},
+		{"2001:db9::5", "eth0", "fe80::1"},
+		{"::1", "lo", "<nil>"},
+	} {
+		r, ok := rt.Lookup(net.ParseIP(tc.ip))
+		if !ok || r.Iface != tc.iface || r.Gateway.String() != tc.gw {
+			t.Errorf("%v should route via %v gateway %v, got %+v", tc.ip, tc.iface, tc.gw, r)
+		}
+	}
+}
+
+// nolint dupl
+func TestRouteLookupNone(t *testing.T) {
+	rt := testRoutes(t)[2:4]
+	if r, ok := rt.Lookup(net.ParseIP("8.8.8.8")); ok {
+		t.Errorf("8.8.8.8 should not have a route, got %+v", r)
+	}
+}
+
+// nolint dupl
+func TestParseRoutesBad(t *testing.T) {
+	if _, err := ParseIPv4Routes(strings.NewReader("header\neth0 bogus\n")); err == nil {
+		t.Errorf("parsing a short line should return an error")
+	}
+	if _, err := ParseIPv6Routes(strings.NewReader("bogus 40 0 00 0 0 0 0 0 eth0\n")); err == nil {
+		t.Errorf("parsing a bad address should return an error")
+	}
+}
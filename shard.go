@@ -0,0 +1,82 @@
+package iputil
+
+import (
+	"hash/fnv"
+	"net"
+	"sync"
+)
+
+// Sharder assigns addresses to buckets using rendezvous (highest random weight) hashing.
+// Addresses are first aggregated to a prefix, so every address in the same prefix is in
+// the same bucket. Adding or removing a bucket only moves the prefixes assigned to it.
+type Sharder struct {
+	mu      sync.RWMutex
+	v4Bits  int
+	v6Bits  int
+	buckets map[string]uint64 // bucket to hash of its name
+}
+
+// NewSharder returns a Sharder which aggregates IPv4 addresses to v4Bits and IPv6
+// addresses to v6Bits, like 24 and 64. ErrInvalidMask is returned if v4Bits is not
+// between 0 and 32, or v6Bits between 0 and 128.
+func NewSharder(v4Bits, v6Bits int, buckets ...string) (*Sharder, error) {
+	if v4Bits < 0 || v4Bits > 32 || v6Bits < 0 || v6Bits > 128 {
+		return nil, ErrInvalidMask
+	}
+	s := &Sharder{v4Bits: v4Bits, v6Bits: v6Bits, buckets: make(map[string]uint64, len(buckets))}
+	for _, b := range buckets {
+		s.Add(b)
+	}
+	return s, nil
+}
+
+// Add adds a bucket
+func (s *Sharder) Add(bucket string) {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(bucket))
+	s.mu.Lock()
+	s.buckets[bucket] = h.Sum64()
+	s.mu.Unlock()
+}
+
+// Remove removes a bucket
+func (s *Sharder) Remove(bucket string) {
+	s.mu.Lock()
+	delete(s.buckets, bucket)
+	s.mu.Unlock()
+}
+
+// Buckets returns the number of buckets
+func (s *Sharder) Buckets() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.buckets)
+}
+
+// Aggregate returns the prefix ip is aggregated to
+func (s *Sharder) Aggregate(ip net.IP) *net.IPNet {
+	if ip4 := ip.To4(); ip4 != nil {
+		return NetworkID(&net.IPNet{IP: ip4, Mask: net.CIDRMask(s.v4Bits, 32)})
+	}
+	return NetworkID(&net.IPNet{IP: ip.To16(), Mask: net.CIDRMask(s.v6Bits, 128)})
+}
+
+// Bucket returns the bucket for ip, or "" if there are no buckets
+func (s *Sharder) Bucket(ip net.IP) string {
+	h := fnv.New64a()
+	_, _ = h.Write(s.Aggregate(ip).IP)
+	k := h.Sum64()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var best string
+	var bestScore uint64
+	found := false
+	for b, bh := range s.buckets {
+		score := mix64(k ^ bh)
+		if !found || score > bestScore || (score == bestScore && b < best) {
+			best, bestScore, found = b, score, true
+		}
+	}
+	return best
+}
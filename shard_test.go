@@ -0,0 +1,119 @@
+package iputil
+
+import (
+	"fmt"
+	"net"
+	"testing"
+)
+
+func testShardIPs() []net.IP {
+	var ips []net.IP
+	ip := net.ParseIP("10.0.0.1")
+	for i := 0; i < 1000; i++ {
+		ips = append(ips, ip)
+		ip = IPAdd(ip, 256)
+	}
+	return ips
+}
+
+// nolint dupl
+func TestShardAggregate(t *testing.T) {
+	s, err := NewSharder(24, 64, "a", "b", "c", "d")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s.Bucket(net.ParseIP("10.1.5.1")) != s.Bucket(net.ParseIP("10.1.5.200")) {
+		t.Errorf("addresses in the same /24 should be in the same bucket")
+	}
+	if s.Bucket(net.ParseIP("2001:db8::1")) != s.Bucket(net.ParseIP("2001:db8::ffff:1")) {
+		t.Errorf("addresses in the same /64 should be in the same bucket")
+	}
+	if n := s.Aggregate(net.ParseIP("2001:db8::1")); n.String() != "2001:db8::/64" {
+		t.Errorf("%v should equal 2001:db8::/64", n)
+	}
+}
+
+// nolint dupl
+func TestShardEmpty(t *testing.T) {
+	s, err := NewSharder(24, 64)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if b := s.Bucket(net.ParseIP("10.1.5.1")); b != "" {
+		t.Errorf("expected no bucket, got %v", b)
+	}
+}
+
+// nolint dupl
+func TestShardDistribution(t *testing.T) {
+	s, err := NewSharder(24, 64)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		s.Add(fmt.Sprintf("worker%v", i))
+	}
+	c := map[string]int{}
+	for _, ip := range testShardIPs() {
+		c[s.Bucket(ip)]++
+	}
+	for b, n := range c {
+		if n < 150 || n > 350 {
+			t.Errorf("bucket %v has %v of 1000 prefixes", b, n)
+		}
+	}
+}
+
+// nolint dupl
+func TestShardAddMovesMinimal(t *testing.T) {
+	s, err := NewSharder(24, 64, "a", "b", "c")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ips := testShardIPs()
+	before := make([]string, len(ips))
+	for i, ip := range ips {
+		before[i] = s.Bucket(ip)
+	}
+	s.Add("d")
+	moved := 0
+	for i, ip := range ips {
+		if b := s.Bucket(ip); b != before[i] {
+			moved++
+			if b != "d" {
+				t.Errorf("%v moved from %v to %v, not the new bucket", ip, before[i], b)
+			}
+		}
+	}
+	if moved == 0 || moved > 350 {
+		t.Errorf("expected about a quarter of prefixes to move, %v moved", moved)
+	}
+	s.Remove("d")
+	for i, ip := range ips {
+		if b := s.Bucket(ip); b != before[i] {
+			t.Errorf("%v should have moved back to %v, got %v", ip, before[i], b)
+		}
+	}
+}
+
+// nolint dupl
+func TestNewSharderBad(t *testing.T) {
+	for _, bits := range [][2]int{{33, 64}, {-1, 64}, {24, 129}, {24, -1}} {
+		if _, err := NewSharder(bits[0], bits[1]); err != ErrInvalidMask {
+			t.Errorf("%v: Expected ErrInvalidMask, got %v", bits, err)
+		}
+	}
+}
+
+// nolint dupl
+func TestShardEmptyName(t *testing.T) {
+	s, _ := NewSharder(24, 64, "", "a", "b")
+	for _, ip := range testShardIPs()[:50] {
+		b := s.Bucket(ip)
+		for i := 0; i < 20; i++ {
+			if b2 := s.Bucket(ip); b2 != b {
+				t.Fatalf("%v: bucket changed from %q to %q", ip, b, b2)
+			}
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package iputil
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// PostgreSQL address families used in the binary format of inet and cidr
+const (
+	pgAFInet  = 2
+	pgAFInet6 = 3
+)
+
+// Inet is a nullable IPNet for the PostgreSQL inet type, implementing sql.Scanner and
+// driver.Valuer. Host bits are preserved, like CIDRToIPNet.
+type Inet struct {
+	net.IPNet
+	Valid bool // Valid is true if Inet is not NULL
+}
+
+// Scan implements sql.Scanner, accepting the text or binary format of inet or cidr
+func (i *Inet) Scan(src interface{}) error {
+	n, err := scanInet(src, "Inet")
+	if err != nil {
+		return err
+	}
+	if n == nil {
+		*i = Inet{}
+		return nil
+	}
+	*i = Inet{IPNet: *n, Valid: true}
+	return nil
+}
+
+// Value implements driver.Valuer, returning the text format of inet
+func (i Inet) Value() (driver.Value, error) {
+	if !i.Valid {
+		return nil, nil
+	}
+	return i.IPNet.String(), nil
+}
+
+// MarshalBinary returns the PostgreSQL binary format of inet
+func (i Inet) MarshalBinary() ([]byte, error) {
+	return encodeInet(&i.IPNet, false)
+}
+
+// UnmarshalBinary parses the PostgreSQL binary format of inet or cidr
+func (i *Inet) UnmarshalBinary(b []byte) error {
+	n, _, err := decodeInet(b)
+	if err != nil {
+		return err
+	}
+	*i = Inet{IPNet: *n, Valid: true}
+	return nil
+}
+
+// CIDR is a nullable IPNet for the PostgreSQL cidr type, implementing sql.Scanner and
+// driver.Valuer. Like PostgreSQL, a CIDR with host bits set is rejected with ErrHostBits.
+type CIDR struct {
+	net.IPNet
+	Valid bool // Valid is true if CIDR is not NULL
+}
+
+// Scan implements sql.Scanner, accepting the text or binary format of cidr or inet
+func (c *CIDR) Scan(src interface{}) error {
+	n, err := scanInet(src, "CIDR")
+	if err != nil {
+		return err
+	}
+	if n == nil {
+		*c = CIDR{}
+		return nil
+	}
+	if err := checkHostBits(n); err != nil {
+		return err
+	}
+	*c = CIDR{IPNet: *n, Valid: true}
+	return nil
+}
+
+// Value implements driver.Valuer, returning the text format of cidr
+func (c CIDR) Value() (driver.Value, error) {
+	if !c.Valid {
+		return nil, nil
+	}
+	if err := checkHostBits(&c.IPNet); err != nil {
+		return nil, err
+	}
+	return c.IPNet.String(), nil
+}
+
+// MarshalBinary returns the PostgreSQL binary format of cidr
+func (c CIDR) MarshalBinary() ([]byte, error) {
+	if err := checkHostBits(&c.IPNet); err != nil {
+		return nil, err
+	}
+	return encodeInet(&c.IPNet, true)
+}
+
+// UnmarshalBinary parses the PostgreSQL binary format of cidr or inet
+func (c *CIDR) UnmarshalBinary(b []byte) error {
+	n, _, err := decodeInet(b)
+	if err != nil {
+		return err
+	}
+	if err := checkHostBits(n); err != nil {
+		return err
+	}
+	*c = CIDR{IPNet: *n, Valid: true}
+	return nil
+}
+
+// checkHostBits returns ErrHostBits if n is not equal to its NetworkID
+func checkHostBits(n *net.IPNet) error {
+	if !n.IP.Equal(NetworkID(n).IP) {
+		return ErrHostBits
+	}
+	return nil
+}
+
+// scanInet converts a database value into an IPNet, or nil for NULL
+func scanInet(src interface{}, typ string) (*net.IPNet, error) {
+	switch v := src.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return parseInet(v)
+	case []byte:
+		if isBinaryInet(v) {
+			n, _, err := decodeInet(v)
+			return n, err
+		}
+		return parseInet(string(v))
+	}
+	return nil, fmt.Errorf("iputil: cannot scan %T into %v", src, typ)
+}
+
+// parseInet parses the text format of inet, where the mask is optional for host addresses
+func parseInet(s string) (*net.IPNet, error) {
+	if strings.Contains(s, "/") {
+		return CIDRToIPNet(s)
+	}
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, &net.ParseError{Type: "inet address", Text: s}
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}, nil
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+}
+
+// isBinaryInet returns true if b looks like the binary format of inet rather than text.
+// The family byte of the binary format is never a printable character.
+func isBinaryInet(b []byte) bool {
+	return len(b) >= 4 && (b[0] == pgAFInet || b[0] == pgAFInet6) && len(b) == 4+int(b[3])
+}
+
+// encodeInet returns the binary format of n: family, prefix length, is cidr, address length, address
+func encodeInet(n *net.IPNet, cidr bool) ([]byte, error) {
+	if err := checkNet(n); err != nil {
+		return nil, err
+	}
+	ones, bits := netSize(n)
+	ip, af := n.IP.To4(), byte(pgAFInet)
+	if bits == 128 {
+		ip, af = n.IP.To16(), pgAFInet6
+	}
+	b := []byte{af, byte(ones), 0, byte(len(ip))}
+	if cidr {
+		b[2] = 1
+	}
+	return append(b, ip...), nil
+}
+
+// decodeInet parses the binary format of inet or cidr
+func decodeInet(b []byte) (*net.IPNet, bool, error) {
+	if !isBinaryInet(b) {
+		return nil, false, fmt.Errorf("iputil: invalid binary inet of length %v", len(b))
+	}
+	l := net.IPv4len
+	if b[0] == pgAFInet6 {
+		l = net.IPv6len
+	}
+	if int(b[3]) != l || int(b[1]) > l*8 {
+		return nil, false, fmt.Errorf("iputil: invalid binary inet")
+	}
+	ip := make(net.IP, l)
+	copy(ip, b[4:])
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(int(b[1]), l*8)}, b[2] != 0, nil
+}
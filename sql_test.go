@@ -0,0 +1,132 @@
+package iputil
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"net"
+	"testing"
+)
+
+var (
+	_ sql.Scanner   = &Inet{}
+	_ driver.Valuer = Inet{}
+	_ sql.Scanner   = &CIDR{}
+	_ driver.Valuer = CIDR{}
+)
+
+// nolint dupl
+func TestInetScanText(t *testing.T) {
+	var i Inet
+	if err := i.Scan([]byte("10.1.0.1/24")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !i.Valid || i.String() != "10.1.0.1/24" {
+		t.Errorf("%v should equal 10.1.0.1/24", i.String())
+	}
+	v, _ := i.Value()
+	if v != "10.1.0.1/24" {
+		t.Errorf("value %v should equal 10.1.0.1/24", v)
+	}
+}
+
+// nolint dupl
+func TestInetScanHost(t *testing.T) {
+	var i Inet
+	if err := i.Scan("fe80::1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if i.String() != "fe80::1/128" {
+		t.Errorf("%v should equal fe80::1/128", i.String())
+	}
+}
+
+// nolint dupl
+func TestInetScanNull(t *testing.T) {
+	i := Inet{Valid: true}
+	if err := i.Scan(nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if i.Valid {
+		t.Errorf("NULL should not be valid")
+	}
+	if v, _ := i.Value(); v != nil {
+		t.Errorf("value of NULL should be nil, got %v", v)
+	}
+	if err := i.Scan(5); err == nil {
+		t.Errorf("scanning an int should return an error")
+	}
+}
+
+// nolint dupl
+func TestInetBinary(t *testing.T) {
+	var i Inet
+	b := []byte{2, 24, 0, 4, 10, 1, 0, 1}
+	if err := i.Scan(b); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if i.String() != "10.1.0.1/24" {
+		t.Errorf("%v should equal 10.1.0.1/24", i.String())
+	}
+	rb, err := i.MarshalBinary()
+	if err != nil || string(rb) != string(b) {
+		t.Errorf("%v should marshal to %v, got %v, %v", i.String(), b, rb, err)
+	}
+}
+
+// nolint dupl
+func TestInetBinary6(t *testing.T) {
+	var i Inet
+	b := []byte{3, 64, 1, 16, 0xfe, 0x80, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}
+	if err := i.UnmarshalBinary(b); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if i.String() != "fe80::/64" {
+		t.Errorf("%v should equal fe80::/64", i.String())
+	}
+	if err := i.UnmarshalBinary([]byte{3, 129, 1, 16, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}); err == nil {
+		t.Errorf("prefix length longer than the address should return an error")
+	}
+}
+
+// nolint dupl
+func TestCIDRScanHostBits(t *testing.T) {
+	var c CIDR
+	if err := c.Scan("10.1.0.1/24"); err != ErrHostBits {
+		t.Errorf("Expected ErrHostBits, got %v", err)
+	}
+	if err := c.Scan("10.1.0.0/24"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v, _ := c.Value(); v != "10.1.0.0/24" {
+		t.Errorf("value %v should equal 10.1.0.0/24", v)
+	}
+}
+
+// nolint dupl
+func TestCIDRValueHostBits(t *testing.T) {
+	n, _ := CIDRToIPNet("10.1.0.1/24")
+	c := CIDR{IPNet: *n, Valid: true}
+	if _, err := c.Value(); err != ErrHostBits {
+		t.Errorf("Expected ErrHostBits, got %v", err)
+	}
+	if _, err := c.MarshalBinary(); err != ErrHostBits {
+		t.Errorf("Expected ErrHostBits, got %v", err)
+	}
+}
+
+// nolint dupl
+func TestCIDRBinary(t *testing.T) {
+	_, n, _ := net.ParseCIDR("10.1.0.0/16")
+	c := CIDR{IPNet: *n, Valid: true}
+	b, err := c.MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(b) != string([]byte{2, 16, 1, 4, 10, 1, 0, 0}) {
+		t.Errorf("unexpected binary %v", b)
+	}
+	var rc CIDR
+	if err := rc.Scan(b); err != nil || rc.String() != "10.1.0.0/16" {
+		t.Errorf("%v should scan to 10.1.0.0/16, got %v, %v", b, rc.String(), err)
+	}
+}
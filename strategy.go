@@ -0,0 +1,334 @@
+package iputil
+
+import (
+	"container/heap"
+	"container/list"
+	"math/bits"
+	"math/rand"
+)
+
+// Strategy chooses which free address a Pool allocates next. Addresses are identified by
+// their offset from the first address in the pool. A Strategy may keep state, so each
+// Pool needs its own, and its methods are called with the pool locked.
+type Strategy interface {
+	// Select returns the offset of an address for which free returns true, or false if there is none
+	Select(size int, free func(offset int) bool) (int, bool)
+	// Allocated is called when an offset is allocated
+	Allocated(offset int)
+	// Released is called when an offset is released
+	Released(offset int)
+}
+
+// scanFrom returns the first free offset, starting at start and wrapping around
+func scanFrom(start, size int, free func(int) bool) (int, bool) {
+	for i := 0; i < size; i++ {
+		o := (start + i) % size
+		if free(o) {
+			return o, true
+		}
+	}
+	return 0, false
+}
+
+type randomStrategy struct{}
+
+// RandomStrategy allocates a random free address. It is the default Strategy of a Pool.
+func RandomStrategy() Strategy { return randomStrategy{} }
+
+func (randomStrategy) Select(size int, free func(int) bool) (int, bool) {
+	return scanFrom(rand.Intn(size), size, free)
+}
+func (randomStrategy) Allocated(int) {}
+func (randomStrategy) Released(int)  {}
+
+type sequentialStrategy struct{}
+
+// SequentialStrategy allocates the lowest free address
+func SequentialStrategy() Strategy { return sequentialStrategy{} }
+
+func (sequentialStrategy) Select(size int, free func(int) bool) (int, bool) {
+	return scanFrom(0, size, free)
+}
+func (sequentialStrategy) Allocated(int) {}
+func (sequentialStrategy) Released(int)  {}
+
+type roundRobinStrategy struct {
+	last int
+}
+
+// RoundRobinStrategy allocates the next free address after the one last allocated,
+// wrapping around at the end of the pool
+func RoundRobinStrategy() Strategy { return &roundRobinStrategy{last: -1} }
+
+func (s *roundRobinStrategy) Select(size int, free func(int) bool) (int, bool) {
+	return scanFrom(s.last+1, size, free)
+}
+func (s *roundRobinStrategy) Allocated(o int) { s.last = o }
+func (s *roundRobinStrategy) Released(int)    {}
+
+type lruStrategy struct {
+	next     int // addresses from next onward may never have been allocated
+	released *list.List
+	elems    map[int]*list.Element
+}
+
+// LRUStrategy allocates addresses which have never been allocated first, in order,
+// then the address which was released the longest time ago. This keeps recently
+// used addresses, which may still be in neighbors' ARP caches, free for as long as possible.
+func LRUStrategy() Strategy {
+	return &lruStrategy{released: list.New(), elems: make(map[int]*list.Element)}
+}
+
+func (s *lruStrategy) Select(size int, free func(int) bool) (int, bool) {
+	for o := s.next; o < size; o++ {
+		if _, ok := s.elems[o]; !ok && free(o) {
+			return o, true
+		}
+	}
+	for e := s.released.Front(); e != nil; e = e.Next() {
+		if o := e.Value.(int); free(o) {
+			return o, true
+		}
+	}
+	return 0, false
+}
+
+func (s *lruStrategy) Allocated(o int) {
+	if e, ok := s.elems[o]; ok {
+		s.released.Remove(e)
+		delete(s.elems, o)
+	}
+	if o == s.next {
+		s.next++
+	}
+}
+
+func (s *lruStrategy) Released(o int) {
+	if e, ok := s.elems[o]; ok {
+		s.released.Remove(e)
+	}
+	s.elems[o] = s.released.PushBack(o)
+}
+
+type maxSpreadStrategy struct {
+	used offsetSet
+	gaps gapHeap // runs of free offsets between allocations, may hold stale gaps
+}
+
+// MaxSpreadStrategy allocates the free address furthest from any allocated address,
+// spreading allocations as sparsely as possible across the pool
+func MaxSpreadStrategy() Strategy {
+	return &maxSpreadStrategy{}
+}
+
+// spreadGap is a run of free offsets from lo to hi, and the offset within it furthest from any allocation
+type spreadGap struct {
+	lo, hi, best, dist int
+}
+
+// between returns the gap between allocated offsets a and b
+func between(a, b int) spreadGap {
+	m := a + (b-a)/2
+	return spreadGap{a + 1, b - 1, m, min(m-a, b-m)}
+}
+
+func (s *maxSpreadStrategy) Select(size int, free func(int) bool) (int, bool) {
+	first, last := s.used.next(0), s.used.prev(size-1)
+	if first < 0 {
+		return searchGap(spreadGap{0, size - 1, 0, size}, free)
+	}
+	// gaps before the first and after the last allocation are outside the heap
+	left := spreadGap{0, first - 1, 0, first}
+	right := spreadGap{last + 1, size - 1, size - 1, size - 1 - last}
+	var tried []spreadGap
+	defer func() {
+		for _, g := range tried {
+			heap.Push(&s.gaps, g)
+		}
+	}()
+	for {
+		for len(s.gaps) > 0 && !s.valid(s.gaps[0]) {
+			heap.Pop(&s.gaps)
+		}
+		// ties go to the lowest gap
+		g, from := right, &right
+		if len(s.gaps) > 0 && s.gaps[0].dist >= g.dist {
+			g, from = s.gaps[0], nil
+		}
+		if left.dist >= g.dist {
+			g, from = left, &left
+		}
+		if g.dist <= 0 || g.lo > g.hi {
+			return 0, false
+		}
+		if o, ok := searchGap(g, free); ok {
+			return o, true
+		}
+		// every offset in g is excluded, try the next best
+		if from != nil {
+			from.dist = 0
+		} else {
+			tried = append(tried, heap.Pop(&s.gaps).(spreadGap))
+		}
+	}
+}
+
+// searchGap returns the free offset in g closest to its best offset
+func searchGap(g spreadGap, free func(int) bool) (int, bool) {
+	for d := 0; g.best-d >= g.lo || g.best+d <= g.hi; d++ {
+		if o := g.best - d; o >= g.lo && o <= g.hi && free(o) {
+			return o, true
+		}
+		if o := g.best + d; d > 0 && o >= g.lo && o <= g.hi && free(o) {
+			return o, true
+		}
+	}
+	return 0, false
+}
+
+// valid returns true if g is still the gap between two consecutive allocations
+func (s *maxSpreadStrategy) valid(g spreadGap) bool {
+	a, b := g.lo-1, g.hi+1
+	return s.used.has(a) && s.used.next(a+1) == b
+}
+
+func (s *maxSpreadStrategy) Allocated(o int) {
+	if s.used.has(o) {
+		return
+	}
+	p, n := s.used.prev(o-1), s.used.next(o+1)
+	s.used.add(o)
+	if p >= 0 && o-p > 1 {
+		heap.Push(&s.gaps, between(p, o))
+	}
+	if n >= 0 && n-o > 1 {
+		heap.Push(&s.gaps, between(o, n))
+	}
+	s.compact()
+}
+
+func (s *maxSpreadStrategy) Released(o int) {
+	if !s.used.has(o) {
+		return
+	}
+	s.used.remove(o)
+	if p, n := s.used.prev(o-1), s.used.next(o+1); p >= 0 && n >= 0 {
+		heap.Push(&s.gaps, between(p, n))
+	}
+	s.compact()
+}
+
+// compact rebuilds the heap when stale gaps outnumber the real ones
+func (s *maxSpreadStrategy) compact() {
+	if len(s.gaps) < 2*s.used.n+64 {
+		return
+	}
+	s.gaps = s.gaps[:0]
+	for a, b := s.used.next(0), -1; a >= 0; a = b {
+		if b = s.used.next(a + 1); b-a > 1 {
+			s.gaps = append(s.gaps, between(a, b))
+		}
+	}
+	heap.Init(&s.gaps)
+}
+
+// gapHeap is a max heap of gaps by distance, then lowest first
+type gapHeap []spreadGap
+
+func (h gapHeap) Len() int { return len(h) }
+func (h gapHeap) Less(i, j int) bool {
+	if h[i].dist != h[j].dist {
+		return h[i].dist > h[j].dist
+	}
+	return h[i].lo < h[j].lo
+}
+func (h gapHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *gapHeap) Push(x interface{}) { *h = append(*h, x.(spreadGap)) }
+func (h *gapHeap) Pop() interface{} {
+	old := *h
+	g := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return g
+}
+
+// offsetSet is a set of non-negative ints, with a summary bitmap of its non-empty words
+// to find the next or previous member quickly
+type offsetSet struct {
+	words   []uint64
+	summary []uint64
+	n       int
+}
+
+func (s *offsetSet) has(o int) bool {
+	return o >= 0 && o/64 < len(s.words) && s.words[o/64]&(1<<(o%64)) != 0
+}
+
+func (s *offsetSet) add(o int) {
+	for o/64 >= len(s.words) {
+		s.words = append(s.words, 0)
+	}
+	for o/4096 >= len(s.summary) {
+		s.summary = append(s.summary, 0)
+	}
+	s.words[o/64] |= 1 << (o % 64)
+	s.summary[o/4096] |= 1 << (o / 64 % 64)
+	s.n++
+}
+
+func (s *offsetSet) remove(o int) {
+	s.words[o/64] &^= 1 << (o % 64)
+	if s.words[o/64] == 0 {
+		s.summary[o/4096] &^= 1 << (o / 64 % 64)
+	}
+	s.n--
+}
+
+// next returns the smallest member at least o, or -1
+func (s *offsetSet) next(o int) int {
+	o = max(o, 0)
+	wi := o / 64
+	if wi >= len(s.words) {
+		return -1
+	}
+	if w := s.words[wi] & (^uint64(0) << (o % 64)); w != 0 {
+		return wi*64 + bits.TrailingZeros64(w)
+	}
+	// find the next non-empty word from the summary
+	wi++
+	for si := wi / 64; si < len(s.summary); si++ {
+		m := s.summary[si]
+		if si == wi/64 {
+			m &= ^uint64(0) << (wi % 64)
+		}
+		if m != 0 {
+			wi = si*64 + bits.TrailingZeros64(m)
+			return wi*64 + bits.TrailingZeros64(s.words[wi])
+		}
+	}
+	return -1
+}
+
+// prev returns the largest member at most o, or -1
+func (s *offsetSet) prev(o int) int {
+	if o < 0 || len(s.words) == 0 {
+		return -1
+	}
+	o = min(o, len(s.words)*64-1)
+	wi := o / 64
+	if w := s.words[wi] & (^uint64(0) >> (63 - o%64)); w != 0 {
+		return wi*64 + 63 - bits.LeadingZeros64(w)
+	}
+	// find the previous non-empty word from the summary
+	wi--
+	for si := wi / 64; wi >= 0 && si >= 0; si-- {
+		m := s.summary[si]
+		if si == wi/64 {
+			m &= ^uint64(0) >> (63 - wi%64)
+		}
+		if m != 0 {
+			wi = si*64 + 63 - bits.LeadingZeros64(m)
+			return wi*64 + 63 - bits.LeadingZeros64(s.words[wi])
+		}
+	}
+	return -1
+}
@@ -0,0 +1,153 @@
+package iputil
+
+import (
+	"math/rand"
+	"net"
+	"testing"
+)
+
+func testStrategyPool(t *testing.T, s Strategy) *Pool {
+	_, sn, _ := net.ParseCIDR("10.1.0.0/29")
+	p, err := NewPool(sn, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.SetStrategy(s)
+	return p
+}
+
+func allocateN(t *testing.T, p *Pool, n int) []string {
+	var ips []string
+	for i := 0; i < n; i++ {
+		ip, err := p.Allocate()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		ips = append(ips, ip.String())
+	}
+	return ips
+}
+
+func checkAllocated(t *testing.T, name string, got []string, expected ...string) {
+	t.Helper()
+	if len(got) != len(expected) {
+		t.Errorf("%v: expected %v, got %v", name, expected, got)
+		return
+	}
+	for i := range got {
+		if got[i] != expected[i] {
+			t.Errorf("%v: expected %v, got %v", name, expected, got)
+			return
+		}
+	}
+}
+
+// nolint dupl
+func TestSequentialStrategy(t *testing.T) {
+	p := testStrategyPool(t, SequentialStrategy())
+	checkAllocated(t, "sequential", allocateN(t, p, 3), "10.1.0.0", "10.1.0.1", "10.1.0.2")
+	_ = p.Release(net.ParseIP("10.1.0.1"))
+	checkAllocated(t, "sequential", allocateN(t, p, 2), "10.1.0.1", "10.1.0.3")
+}
+
+// nolint dupl
+func TestRoundRobinStrategy(t *testing.T) {
+	p := testStrategyPool(t, RoundRobinStrategy())
+	checkAllocated(t, "round robin", allocateN(t, p, 3), "10.1.0.0", "10.1.0.1", "10.1.0.2")
+	_ = p.Release(net.ParseIP("10.1.0.1"))
+	checkAllocated(t, "round robin", allocateN(t, p, 6), "10.1.0.3", "10.1.0.4", "10.1.0.5", "10.1.0.6", "10.1.0.7", "10.1.0.1")
+}
+
+// nolint dupl
+func TestLRUStrategy(t *testing.T) {
+	p := testStrategyPool(t, LRUStrategy())
+	checkAllocated(t, "lru", allocateN(t, p, 4), "10.1.0.0", "10.1.0.1", "10.1.0.2", "10.1.0.3")
+	_ = p.Release(net.ParseIP("10.1.0.2"))
+	_ = p.Release(net.ParseIP("10.1.0.0"))
+	checkAllocated(t, "lru", allocateN(t, p, 5), "10.1.0.4", "10.1.0.5", "10.1.0.6", "10.1.0.7", "10.1.0.2")
+	_ = p.Release(net.ParseIP("10.1.0.5"))
+	checkAllocated(t, "lru", allocateN(t, p, 2), "10.1.0.0", "10.1.0.5")
+}
+
+// nolint dupl
+func TestMaxSpreadStrategy(t *testing.T) {
+	p := testStrategyPool(t, MaxSpreadStrategy())
+	checkAllocated(t, "max spread", allocateN(t, p, 3), "10.1.0.0", "10.1.0.7", "10.1.0.3")
+	p.SetExclude(func(ip net.IP) bool { return ip.Equal(net.ParseIP("10.1.0.5")) })
+	checkAllocated(t, "max spread", allocateN(t, p, 2), "10.1.0.4", "10.1.0.1")
+}
+
+// nolint dupl
+func TestSetStrategyExisting(t *testing.T) {
+	_, sn, _ := net.ParseCIDR("10.1.0.0/29")
+	p, _ := NewPool(sn, 0, 0)
+	_ = p.AllocateIP(net.ParseIP("10.1.0.0"))
+	_ = p.AllocateIP(net.ParseIP("10.1.0.7"))
+	p.SetStrategy(MaxSpreadStrategy())
+	checkAllocated(t, "existing", allocateN(t, p, 1), "10.1.0.3")
+}
+
+// nolint dupl
+func TestMaxSpreadStrategyRandom(t *testing.T) {
+	const size = 300
+	s := MaxSpreadStrategy()
+	used := map[int]bool{}
+	free := func(o int) bool { return !used[o] }
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 5000; i++ {
+		if len(used) > 0 && (len(used) == size || r.Intn(3) == 0) {
+			// release a random allocation
+			for o := range used {
+				delete(used, o)
+				s.Released(o)
+				break
+			}
+			continue
+		}
+		// the reference is the free offset furthest from any allocation, lowest first
+		e, ed := -1, -1
+		for o := 0; o < size; o++ {
+			if used[o] {
+				continue
+			}
+			d := size
+			for u := range used {
+				d = min(d, max(u-o, o-u))
+			}
+			if d > ed {
+				e, ed = o, d
+			}
+		}
+		o, ok := s.Select(size, free)
+		if !ok || o != e {
+			t.Fatalf("step %v: expected %v, got %v, %v", i, e, o, ok)
+		}
+		used[o] = true
+		s.Allocated(o)
+	}
+}
+
+// nolint dupl
+func TestOffsetSet(t *testing.T) {
+	var s offsetSet
+	for _, o := range []int{5, 4100, 300000} {
+		s.add(o)
+	}
+	for _, v := range []struct{ o, next, prev int }{
+		{0, 5, -1},
+		{5, 5, 5},
+		{6, 4100, 5},
+		{4099, 4100, 5},
+		{4101, 300000, 4100},
+		{300001, -1, 300000},
+		{1 << 30, -1, 300000},
+	} {
+		if n, p := s.next(v.o), s.prev(v.o); n != v.next || p != v.prev {
+			t.Errorf("%v: expected next %v prev %v, got %v %v", v.o, v.next, v.prev, n, p)
+		}
+	}
+	s.remove(4100)
+	if n, p := s.next(6), s.prev(299999); n != 300000 || p != 5 || s.n != 2 {
+		t.Errorf("unexpected next %v prev %v after remove", n, p)
+	}
+}
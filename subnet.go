@@ -0,0 +1,77 @@
+package iputil
+
+import (
+	"math/big"
+	"net"
+)
+
+// NextSubnet returns the subnet of the same size immediately after n, so the next subnet
+// after 10.1.5.0/24 is 10.1.6.0/24. Host bits in n are ignored. ErrOverflow is returned
+// if n is the last subnet of its size in the address space.
+func NextSubnet(n *net.IPNet) (*net.IPNet, error) {
+	return stepSubnet(n, 1)
+}
+
+// PrevSubnet returns the subnet of the same size immediately before n. ErrOverflow is
+// returned if n is the first subnet of its size in the address space.
+func PrevSubnet(n *net.IPNet) (*net.IPNet, error) {
+	return stepSubnet(n, -1)
+}
+
+func stepSubnet(n *net.IPNet, dir int64) (*net.IPNet, error) {
+	if err := checkNet(n); err != nil {
+		return nil, err
+	}
+	c := canonicalNet(n)
+	ones, bits := netSize(c)
+	i := new(big.Int).Mul(hostCount(ones, bits), big.NewInt(dir))
+	i.Add(i, ipToInt(c.IP))
+	if i.Sign() < 0 || i.BitLen() > bits {
+		return nil, ErrOverflow
+	}
+	return &net.IPNet{IP: intToIP(i, c.IP), Mask: c.Mask}, nil
+}
+
+// Parent returns the supernet of n with a prefix length of bits, so the parent /16 of
+// 10.1.5.0/24 is 10.1.0.0/16. ErrInvalidMask is returned if bits is negative or longer
+// than the prefix length of n.
+func Parent(n *net.IPNet, bits int) (*net.IPNet, error) {
+	if err := checkNet(n); err != nil {
+		return nil, err
+	}
+	c := canonicalNet(n)
+	ones, max := netSize(c)
+	if bits < 0 || bits > ones {
+		return nil, ErrInvalidMask
+	}
+	return NetworkID(&net.IPNet{IP: c.IP, Mask: net.CIDRMask(bits, max)}), nil
+}
+
+// Sibling returns the other half of the parent of n, one bit shorter, so the sibling
+// of 10.1.4.0/24 is 10.1.5.0/24. ErrInvalidMask is returned if n is a /0, which has no parent.
+func Sibling(n *net.IPNet) (*net.IPNet, error) {
+	if err := checkNet(n); err != nil {
+		return nil, err
+	}
+	c := canonicalNet(n)
+	ones, _ := netSize(c)
+	if ones == 0 {
+		return nil, ErrInvalidMask
+	}
+	c.IP[(ones-1)/8] ^= 0x80 >> uint((ones-1)%8)
+	return c, nil
+}
+
+// Children returns the two halves of n, one bit longer, so the children of 10.1.4.0/23
+// are 10.1.4.0/24 and 10.1.5.0/24. ErrInvalidMask is returned if n is a single address.
+func Children(n *net.IPNet) (*net.IPNet, *net.IPNet, error) {
+	if err := checkNet(n); err != nil {
+		return nil, nil, err
+	}
+	c := canonicalNet(n)
+	if ones, bits := netSize(c); ones == bits {
+		return nil, nil, ErrInvalidMask
+	}
+	lo, hi := splitNet(c)
+	return lo, hi, nil
+}
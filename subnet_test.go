@@ -0,0 +1,111 @@
+package iputil
+
+import (
+	"net"
+	"testing"
+)
+
+// nolint dupl
+func TestNextSubnet(t *testing.T) {
+	for _, v := range []struct{ n, e string }{
+		{"10.1.5.0/24", "10.1.6.0/24"},
+		{"10.1.5.7/24", "10.1.6.0/24"},
+		{"10.1.255.0/24", "10.2.0.0/24"},
+		{"2001:db8::/64", "2001:db8:0:1::/64"},
+		{"2001:db8:ffff:ffff::/64", "2001:db9::/64"},
+	} {
+		n, err := NextSubnet(mustCIDR(v.n))
+		if err != nil || n.String() != v.e {
+			t.Errorf("%v: expected %v, got %v, %v", v.n, v.e, n, err)
+		}
+	}
+	for _, s := range []string{"255.255.255.0/24", "ffff::/16", "0.0.0.0/0"} {
+		if _, err := NextSubnet(mustCIDR(s)); err != ErrOverflow {
+			t.Errorf("%v: Expected ErrOverflow, got %v", s, err)
+		}
+	}
+	if _, err := NextSubnet(&net.IPNet{IP: net.ParseIP("10.0.0.0")}); err != ErrInvalidMask {
+		t.Errorf("Expected ErrInvalidMask, got %v", err)
+	}
+}
+
+// nolint dupl
+func TestPrevSubnet(t *testing.T) {
+	for _, v := range []struct{ n, e string }{
+		{"10.1.5.0/24", "10.1.4.0/24"},
+		{"10.2.0.0/24", "10.1.255.0/24"},
+		{"2001:db9::/64", "2001:db8:ffff:ffff::/64"},
+		{"0.0.0.1/32", "0.0.0.0/32"},
+	} {
+		n, err := PrevSubnet(mustCIDR(v.n))
+		if err != nil || n.String() != v.e {
+			t.Errorf("%v: expected %v, got %v, %v", v.n, v.e, n, err)
+		}
+	}
+	for _, s := range []string{"0.0.0.0/24", "::/64"} {
+		if _, err := PrevSubnet(mustCIDR(s)); err != ErrOverflow {
+			t.Errorf("%v: Expected ErrOverflow, got %v", s, err)
+		}
+	}
+}
+
+// nolint dupl
+func TestParent(t *testing.T) {
+	for _, v := range []struct {
+		n    string
+		bits int
+		e    string
+	}{
+		{"10.1.5.0/24", 23, "10.1.4.0/23"},
+		{"10.1.5.0/24", 16, "10.1.0.0/16"},
+		{"10.1.5.0/24", 24, "10.1.5.0/24"},
+		{"10.1.5.0/24", 0, "0.0.0.0/0"},
+		{"2001:db8:1234::/48", 32, "2001:db8::/32"},
+	} {
+		n, err := Parent(mustCIDR(v.n), v.bits)
+		if err != nil || n.String() != v.e {
+			t.Errorf("%v, %v: expected %v, got %v, %v", v.n, v.bits, v.e, n, err)
+		}
+	}
+	for _, bits := range []int{-1, 25} {
+		if _, err := Parent(mustCIDR("10.1.5.0/24"), bits); err != ErrInvalidMask {
+			t.Errorf("%v: Expected ErrInvalidMask, got %v", bits, err)
+		}
+	}
+}
+
+// nolint dupl
+func TestSibling(t *testing.T) {
+	for _, v := range []struct{ n, e string }{
+		{"10.1.4.0/24", "10.1.5.0/24"},
+		{"10.1.5.0/24", "10.1.4.0/24"},
+		{"10.1.5.9/32", "10.1.5.8/32"},
+		{"128.0.0.0/1", "0.0.0.0/1"},
+		{"2001:db8::/33", "2001:db8:8000::/33"},
+	} {
+		n, err := Sibling(mustCIDR(v.n))
+		if err != nil || n.String() != v.e {
+			t.Errorf("%v: expected %v, got %v, %v", v.n, v.e, n, err)
+		}
+	}
+	if _, err := Sibling(mustCIDR("::/0")); err != ErrInvalidMask {
+		t.Errorf("Expected ErrInvalidMask, got %v", err)
+	}
+}
+
+// nolint dupl
+func TestChildren(t *testing.T) {
+	for _, v := range []struct{ n, lo, hi string }{
+		{"10.1.4.0/23", "10.1.4.0/24", "10.1.5.0/24"},
+		{"0.0.0.0/0", "0.0.0.0/1", "128.0.0.0/1"},
+		{"2001:db8::/127", "2001:db8::/128", "2001:db8::1/128"},
+	} {
+		lo, hi, err := Children(mustCIDR(v.n))
+		if err != nil || lo.String() != v.lo || hi.String() != v.hi {
+			t.Errorf("%v: expected %v %v, got %v %v, %v", v.n, v.lo, v.hi, lo, hi, err)
+		}
+	}
+	if _, _, err := Children(mustCIDR("10.1.1.1/32")); err != ErrInvalidMask {
+		t.Errorf("Expected ErrInvalidMask, got %v", err)
+	}
+}
@@ -0,0 +1,196 @@
+package iputil
+
+import (
+	"encoding/binary"
+	"math/big"
+	"math/bits"
+	"net"
+)
+
+// IPToUint32 returns an IPv4 address as an integer. false is returned if ip is not IPv4.
+func IPToUint32(ip net.IP) (uint32, bool) {
+	ip4 := ip.To4()
+	if ip4 == nil {
+		return 0, false
+	}
+	return binary.BigEndian.Uint32(ip4), true
+}
+
+// Uint32ToIP returns the 4 byte IPv4 address of an integer
+func Uint32ToIP(i uint32) net.IP {
+	return binary.BigEndian.AppendUint32(make(net.IP, 0, net.IPv4len), i)
+}
+
+// IPToBig returns an address as an integer. IPv4 addresses, in either form, are in the
+// range of 32 bits. nil is returned if ip is not a valid address.
+func IPToBig(ip net.IP) *big.Int {
+	if len(ip) != net.IPv4len && len(ip) != net.IPv6len {
+		return nil
+	}
+	return ipToInt(ip)
+}
+
+// BigToIP returns the IPv4 or IPv6 address of an integer. ErrOverflow is returned if i
+// is negative or does not fit in the address family.
+func BigToIP(i *big.Int, ipv6 bool) (net.IP, error) {
+	like := net.IP(make([]byte, net.IPv4len))
+	if ipv6 {
+		like = net.IPv6zero
+	}
+	if i.Sign() < 0 || uint(i.BitLen()) > 8*uint(len(like)) {
+		return nil, ErrOverflow
+	}
+	return intToIP(new(big.Int).Set(i), like), nil
+}
+
+// IPAddBig is IPAddChecked with an offset of any size
+func IPAddBig(ip net.IP, offset *big.Int) (net.IP, error) {
+	i := IPToBig(ip)
+	if i == nil {
+		return nil, ErrFamilyMismatch
+	}
+	i.Add(i, offset)
+	if i.Sign() < 0 || uint(i.BitLen()) > addrBits(ip) {
+		return nil, ErrOverflow
+	}
+	return intToIP(i, ip), nil
+}
+
+// IPDiffBig is IPDiffChecked with a result of any size
+func IPDiffBig(ip, ip2 net.IP) (*big.Int, error) {
+	if err := checkFamily(ip, ip2); err != nil {
+		return nil, err
+	}
+	ip, ip2 = makeNilZero(ip, ip2)
+	d := ipToInt(ip)
+	return d.Sub(d, ipToInt(ip2)), nil
+}
+
+// Uint128 is an unsigned 128 bit integer, large enough to hold an IPv6 address.
+// Arithmetic wraps around like Go's unsigned integer types.
+type Uint128 struct {
+	Hi, Lo uint64
+}
+
+// IPToUint128 returns an address as a Uint128. IPv4 addresses are converted in their
+// 16 byte IPv4-mapped form, so 10.0.0.1 is ::ffff:10.0.0.1. false is returned if ip is
+// not a valid address.
+func IPToUint128(ip net.IP) (Uint128, bool) {
+	ip16 := ip.To16()
+	if ip16 == nil {
+		return Uint128{}, false
+	}
+	return Uint128{binary.BigEndian.Uint64(ip16[:8]), binary.BigEndian.Uint64(ip16[8:])}, true
+}
+
+// BigToUint128 converts i to a Uint128. false is returned if i is negative or more than 128 bits.
+func BigToUint128(i *big.Int) (Uint128, bool) {
+	if i.Sign() < 0 || i.BitLen() > 128 {
+		return Uint128{}, false
+	}
+	var b [16]byte
+	i.FillBytes(b[:])
+	return Uint128{binary.BigEndian.Uint64(b[:8]), binary.BigEndian.Uint64(b[8:])}, true
+}
+
+// IP returns u as a 16 byte address
+func (u Uint128) IP() net.IP {
+	ip := make(net.IP, 0, net.IPv6len)
+	ip = binary.BigEndian.AppendUint64(ip, u.Hi)
+	return binary.BigEndian.AppendUint64(ip, u.Lo)
+}
+
+// Big returns u as a big.Int
+func (u Uint128) Big() *big.Int {
+	i := new(big.Int).SetUint64(u.Hi)
+	i.Lsh(i, 64)
+	return i.Or(i, new(big.Int).SetUint64(u.Lo))
+}
+
+// String returns u in decimal
+func (u Uint128) String() string {
+	return u.Big().String()
+}
+
+// Cmp returns -1, 0 or 1 if u is less than, equal to or greater than v
+func (u Uint128) Cmp(v Uint128) int {
+	if c := compareUint64(u.Hi, v.Hi); c != 0 {
+		return c
+	}
+	return compareUint64(u.Lo, v.Lo)
+}
+
+func compareUint64(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// IsZero returns true if u is 0
+func (u Uint128) IsZero() bool {
+	return u.Hi == 0 && u.Lo == 0
+}
+
+// Add returns u+v
+func (u Uint128) Add(v Uint128) Uint128 {
+	lo, c := bits.Add64(u.Lo, v.Lo, 0)
+	hi, _ := bits.Add64(u.Hi, v.Hi, c)
+	return Uint128{hi, lo}
+}
+
+// Sub returns u-v
+func (u Uint128) Sub(v Uint128) Uint128 {
+	lo, b := bits.Sub64(u.Lo, v.Lo, 0)
+	hi, _ := bits.Sub64(u.Hi, v.Hi, b)
+	return Uint128{hi, lo}
+}
+
+// AddUint64 returns u+v
+func (u Uint128) AddUint64(v uint64) Uint128 {
+	return u.Add(Uint128{Lo: v})
+}
+
+// SubUint64 returns u-v
+func (u Uint128) SubUint64(v uint64) Uint128 {
+	return u.Sub(Uint128{Lo: v})
+}
+
+// And returns u&v
+func (u Uint128) And(v Uint128) Uint128 {
+	return Uint128{u.Hi & v.Hi, u.Lo & v.Lo}
+}
+
+// Or returns u|v
+func (u Uint128) Or(v Uint128) Uint128 {
+	return Uint128{u.Hi | v.Hi, u.Lo | v.Lo}
+}
+
+// Xor returns u^v
+func (u Uint128) Xor(v Uint128) Uint128 {
+	return Uint128{u.Hi ^ v.Hi, u.Lo ^ v.Lo}
+}
+
+// Not returns ^u
+func (u Uint128) Not() Uint128 {
+	return Uint128{^u.Hi, ^u.Lo}
+}
+
+// Lsh returns u<<n
+func (u Uint128) Lsh(n uint) Uint128 {
+	if n >= 64 {
+		return Uint128{Hi: u.Lo << (n - 64)}
+	}
+	return Uint128{u.Hi<<n | u.Lo>>(64-n), u.Lo << n}
+}
+
+// Rsh returns u>>n
+func (u Uint128) Rsh(n uint) Uint128 {
+	if n >= 64 {
+		return Uint128{Lo: u.Hi >> (n - 64)}
+	}
+	return Uint128{u.Hi >> n, u.Lo>>n | u.Hi<<(64-n)}
+}
@@ -0,0 +1,128 @@
+package iputil
+
+import (
+	"math/big"
+	"net"
+	"testing"
+)
+
+// nolint dupl
+func TestIPToUint32(t *testing.T) {
+	for _, s := range []string{"10.1.0.1", "::ffff:10.1.0.1"} {
+		if i, ok := IPToUint32(net.ParseIP(s)); !ok || i != 0x0a010001 {
+			t.Errorf("%v: expected 0x0a010001, got %x, %v", s, i, ok)
+		}
+	}
+	if _, ok := IPToUint32(net.ParseIP("2001:db8::1")); ok {
+		t.Errorf("IPv6 address should not convert")
+	}
+	if ip := Uint32ToIP(0x0a010001); len(ip) != net.IPv4len || !ip.Equal(net.ParseIP("10.1.0.1")) {
+		t.Errorf("expected 10.1.0.1, got %v", ip)
+	}
+}
+
+// nolint dupl
+func TestIPToBig(t *testing.T) {
+	i := IPToBig(net.ParseIP("2001:db8::1"))
+	e, _ := new(big.Int).SetString("20010db8000000000000000000000001", 16)
+	if i.Cmp(e) != 0 {
+		t.Errorf("expected %v, got %v", e, i)
+	}
+	if ip, err := BigToIP(i, true); err != nil || !ip.Equal(net.ParseIP("2001:db8::1")) {
+		t.Errorf("expected 2001:db8::1, got %v, %v", ip, err)
+	}
+	if i := IPToBig(net.ParseIP("10.1.0.1")); i.Int64() != 0x0a010001 {
+		t.Errorf("expected 0x0a010001, got %x", i)
+	}
+	if ip, err := BigToIP(big.NewInt(0x0a010001), false); err != nil || ip.String() != "10.1.0.1" {
+		t.Errorf("expected 10.1.0.1, got %v, %v", ip, err)
+	}
+	if _, err := BigToIP(big.NewInt(1<<32), false); err != ErrOverflow {
+		t.Errorf("Expected ErrOverflow, got %v", err)
+	}
+	if _, err := BigToIP(big.NewInt(-1), true); err != ErrOverflow {
+		t.Errorf("Expected ErrOverflow, got %v", err)
+	}
+	if i := IPToBig(net.IP{1, 2, 3}); i != nil {
+		t.Errorf("expected nil, got %v", i)
+	}
+}
+
+// nolint dupl
+func TestIPAddDiffBig(t *testing.T) {
+	off := new(big.Int).Lsh(big.NewInt(1), 64)
+	ip, err := IPAddBig(net.ParseIP("2001:db8::"), off)
+	if err != nil || !ip.Equal(net.ParseIP("2001:db8:0:1::")) {
+		t.Errorf("expected 2001:db8:0:1::, got %v, %v", ip, err)
+	}
+	d, err := IPDiffBig(ip, net.ParseIP("2001:db8::"))
+	if err != nil || d.Cmp(off) != 0 {
+		t.Errorf("expected %v, got %v, %v", off, d, err)
+	}
+	if _, err := IPAddBig(net.ParseIP("255.255.255.255"), big.NewInt(1)); err != ErrOverflow {
+		t.Errorf("Expected ErrOverflow, got %v", err)
+	}
+	if _, err := IPDiffBig(net.ParseIP("10.0.0.1"), net.ParseIP("::1")); err != ErrFamilyMismatch {
+		t.Errorf("Expected ErrFamilyMismatch, got %v", err)
+	}
+}
+
+// nolint dupl
+func TestUint128(t *testing.T) {
+	u, ok := IPToUint128(net.ParseIP("2001:db8::ffff:ffff:ffff:ffff"))
+	if !ok || u.Hi != 0x20010db800000000 || u.Lo != ^uint64(0) {
+		t.Fatalf("unexpected %x, %v", u, ok)
+	}
+	n := u.AddUint64(1)
+	if !n.IP().Equal(net.ParseIP("2001:db8:0:1::")) {
+		t.Errorf("expected carry into the high word, got %v", n.IP())
+	}
+	if n.Sub(u) != (Uint128{Lo: 1}) || n.SubUint64(1) != u {
+		t.Errorf("unexpected subtraction %v", n.Sub(u))
+	}
+	if n.Cmp(u) != 1 || u.Cmp(n) != -1 || u.Cmp(u) != 0 {
+		t.Errorf("unexpected comparison")
+	}
+	if (Uint128{}).SubUint64(1) != (Uint128{^uint64(0), ^uint64(0)}) {
+		t.Errorf("subtraction should wrap")
+	}
+	b, ok := BigToUint128(u.Big())
+	if !ok || b != u || u.Big().Cmp(IPToBig(u.IP())) != 0 {
+		t.Errorf("expected %v, got %v", u, b)
+	}
+	if u.String() != IPToBig(u.IP()).String() {
+		t.Errorf("expected %v, got %v", IPToBig(u.IP()), u)
+	}
+	if _, ok := BigToUint128(new(big.Int).Lsh(big.NewInt(1), 128)); ok {
+		t.Errorf("129 bit integer should not convert")
+	}
+	if v4, _ := IPToUint128(net.ParseIP("10.0.0.1")); v4 != (Uint128{Lo: 0xffff0a000001}) {
+		t.Errorf("expected IPv4-mapped value, got %x", v4)
+	}
+}
+
+// nolint dupl
+func TestUint128Bits(t *testing.T) {
+	u := Uint128{Hi: 1, Lo: 1 << 63}
+	for _, v := range []struct {
+		got, e Uint128
+	}{
+		{u.Lsh(1), Uint128{Hi: 3}},
+		{u.Lsh(64), Uint128{Hi: 1 << 63}},
+		{u.Lsh(0), u},
+		{u.Rsh(1), Uint128{Lo: 3 << 62}},
+		{u.Rsh(64), Uint128{Lo: 1}},
+		{u.Rsh(128), Uint128{}},
+		{u.And(Uint128{Hi: 1}), Uint128{Hi: 1}},
+		{u.Or(Uint128{Lo: 1}), Uint128{Hi: 1, Lo: 1<<63 | 1}},
+		{u.Xor(u), Uint128{}},
+		{u.Not(), Uint128{^uint64(1), ^uint64(1 << 63)}},
+	} {
+		if v.got != v.e {
+			t.Errorf("expected %x, got %x", v.e, v.got)
+		}
+	}
+	if !(Uint128{}).IsZero() || u.IsZero() {
+		t.Errorf("unexpected IsZero")
+	}
+}
@@ -0,0 +1,142 @@
+package iputil
+
+import (
+	"net"
+	"strconv"
+	"strings"
+)
+
+// ZonedIPNet is an IPNet carrying an IPv6 zone (scope ID), like fe80::1%eth0/64
+type ZonedIPNet struct {
+	net.IPNet
+	Zone string
+}
+
+// String returns the CIDR notation of z, with the zone following the address
+func (z *ZonedIPNet) String() string {
+	if z == nil {
+		return "<nil>"
+	}
+	s := z.IPNet.String()
+	if z.Zone == "" {
+		return s
+	}
+	i := strings.LastIndexByte(s, '/')
+	if i < 0 {
+		return s
+	}
+	return s[:i] + "%" + z.Zone + s[i:]
+}
+
+// FirstAddr returns the first address in z, keeping its zone
+func (z *ZonedIPNet) FirstAddr() *net.IPAddr {
+	return &net.IPAddr{IP: FirstAddr(&z.IPNet), Zone: z.Zone}
+}
+
+// LastAddr returns the last address in z, keeping its zone
+func (z *ZonedIPNet) LastAddr() *net.IPAddr {
+	return &net.IPAddr{IP: LastAddr(&z.IPNet), Zone: z.Zone}
+}
+
+// NetworkID returns the network of z, keeping its zone
+func (z *ZonedIPNet) NetworkID() *ZonedIPNet {
+	return &ZonedIPNet{IPNet: *NetworkID(&z.IPNet), Zone: z.Zone}
+}
+
+// Contains returns true if a is in z and both are in the same zone
+func (z *ZonedIPNet) Contains(a *net.IPAddr) bool {
+	if a == nil || a.Zone != z.Zone {
+		return false
+	}
+	return z.IPNet.Contains(a.IP)
+}
+
+// ParseZonedIP parses an IP address with an optional zone, like "fe80::1%eth0"
+func ParseZonedIP(s string) (*net.IPAddr, error) {
+	ip, zone, err := splitZone(s, s)
+	if err != nil {
+		return nil, err
+	}
+	return &net.IPAddr{IP: ip, Zone: zone}, nil
+}
+
+// ParseZonedCIDR parses a CIDR with an optional zone, like "fe80::1%eth0/64".
+// Like CIDRToIPNet, the host bits of the address are preserved.
+func ParseZonedCIDR(s string) (*ZonedIPNet, error) {
+	i := strings.LastIndexByte(s, '/')
+	if i < 0 {
+		return nil, &net.ParseError{Type: "CIDR address", Text: s}
+	}
+	_, zone, err := splitZone(s[:i], s)
+	if err != nil {
+		return nil, err
+	}
+	cidr := s
+	if z := strings.IndexByte(s[:i], '%'); z >= 0 {
+		cidr = s[:z] + s[i:]
+	}
+	n, err := CIDRToIPNet(cidr)
+	if err != nil {
+		return nil, &net.ParseError{Type: "CIDR address", Text: s}
+	}
+	return &ZonedIPNet{IPNet: *n, Zone: zone}, nil
+}
+
+// splitZone parses addr into an IP and zone. Zones are only allowed on IPv6 addresses.
+// orig is the full input, used in errors.
+func splitZone(addr, orig string) (net.IP, string, error) {
+	zone := ""
+	if i := strings.IndexByte(addr, '%'); i >= 0 {
+		addr, zone = addr[:i], addr[i+1:]
+		if zone == "" {
+			return nil, "", &net.ParseError{Type: "IP address", Text: orig}
+		}
+	}
+	ip := net.ParseIP(addr)
+	if ip == nil || (zone != "" && !strings.Contains(addr, ":")) {
+		return nil, "", &net.ParseError{Type: "IP address", Text: orig}
+	}
+	return ip, zone, nil
+}
+
+// ZonedIPAdd adds an offset to an IP, keeping its zone. nil is returned for a nil address.
+func ZonedIPAdd(a *net.IPAddr, offset int) *net.IPAddr {
+	if a == nil {
+		return nil
+	}
+	return &net.IPAddr{IP: IPAdd(a.IP, offset), Zone: a.Zone}
+}
+
+// ZonedIPEqual returns true if a and b are the same address in the same zone
+func ZonedIPEqual(a, b *net.IPAddr) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Zone == b.Zone && a.IP.Equal(b.IP)
+}
+
+// ZonedIPBefore returns true if a < b. Addresses are compared first,
+// equal addresses are then ordered by zone. nil is before any address.
+func ZonedIPBefore(a, b *net.IPAddr) bool {
+	if a == nil || b == nil {
+		return a == nil && b != nil
+	}
+	if a.IP.Equal(b.IP) {
+		return a.Zone < b.Zone
+	}
+	return IPBefore(a.IP, b.IP)
+}
+
+// ZoneIndex returns the interface index for a zone.
+// Numeric zones are returned as is.
+func ZoneIndex(zone string) (int, error) {
+	if i, err := strconv.Atoi(zone); err == nil && i > 0 {
+		return i, nil
+	}
+	return zoneIndex(zone)
+}
+
+// ZoneName returns the interface name for an interface index
+func ZoneName(index int) (string, error) {
+	return zoneName(index)
+}
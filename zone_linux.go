@@ -0,0 +1,38 @@
+package iputil
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+var errNoSuchZone = errors.New("no such network interface")
+
+const sysClassNet = "/sys/class/net"
+
+// zoneIndex reads the interface index from sysfs, avoiding a netlink dump of all interfaces
+func zoneIndex(zone string) (int, error) {
+	if zone == "" || strings.ContainsAny(zone, "/\x00") || zone == "." || zone == ".." {
+		return 0, errNoSuchZone
+	}
+	b, err := os.ReadFile(filepath.Join(sysClassNet, zone, "ifindex"))
+	if err != nil {
+		return 0, errNoSuchZone
+	}
+	return strconv.Atoi(strings.TrimSpace(string(b)))
+}
+
+func zoneName(index int) (string, error) {
+	ents, err := os.ReadDir(sysClassNet)
+	if err != nil {
+		return "", err
+	}
+	for _, e := range ents {
+		if i, err := zoneIndex(e.Name()); err == nil && i == index {
+			return e.Name(), nil
+		}
+	}
+	return "", errNoSuchZone
+}
@@ -0,0 +1,21 @@
+//go:build !linux
+
+package iputil
+
+import "net"
+
+func zoneIndex(zone string) (int, error) {
+	ifi, err := net.InterfaceByName(zone)
+	if err != nil {
+		return 0, err
+	}
+	return ifi.Index, nil
+}
+
+func zoneName(index int) (string, error) {
+	ifi, err := net.InterfaceByIndex(index)
+	if err != nil {
+		return "", err
+	}
+	return ifi.Name, nil
+}
@@ -0,0 +1,139 @@
+package iputil
+
+import (
+	"net"
+	"testing"
+)
+
+// nolint dupl
+func TestParseZonedCIDR(t *testing.T) {
+	z, err := ParseZonedCIDR("fe80::1%eth0/64")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if z.Zone != "eth0" {
+		t.Errorf("zone should be eth0, got %v", z.Zone)
+	}
+	if !z.IP.Equal(net.ParseIP("fe80::1")) {
+		t.Errorf("host bits of %v should be preserved", z.String())
+	}
+	if z.String() != "fe80::1%eth0/64" {
+		t.Errorf("%v should format as fe80::1%%eth0/64", z.String())
+	}
+}
+
+// nolint dupl
+func TestParseZonedCIDRNoZone(t *testing.T) {
+	z, err := ParseZonedCIDR("10.1.0.1/24")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if z.Zone != "" || z.String() != "10.1.0.1/24" {
+		t.Errorf("%v should equal 10.1.0.1/24 with no zone", z.String())
+	}
+	z, err = ParseZonedCIDR("::ffff:10.0.0.1/120")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ones, bits := z.Mask.Size(); ones != 120 || bits != 128 || !z.IP.Equal(net.ParseIP("10.0.0.1")) {
+		t.Errorf("%v should equal ::ffff:10.0.0.1/120", z.String())
+	}
+}
+
+// nolint dupl
+func TestParseZonedCIDRBad(t *testing.T) {
+	for _, s := range []string{"fe80::1%eth0", "10.1.0.1%eth0/24", "fe80::1%/64", "fe80::1%eth0/129"} {
+		if _, err := ParseZonedCIDR(s); err == nil {
+			t.Errorf("parsing %v should return an error", s)
+		}
+	}
+}
+
+// nolint dupl
+func TestParseZonedIP(t *testing.T) {
+	a, err := ParseZonedIP("fe80::1%eth0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if a.Zone != "eth0" || a.String() != "fe80::1%eth0" {
+		t.Errorf("%v should equal fe80::1%%eth0", a.String())
+	}
+}
+
+// nolint dupl
+func TestZonedFirstLastAddr(t *testing.T) {
+	z, _ := ParseZonedCIDR("fe80::1%eth0/64")
+	if f := z.FirstAddr(); f.String() != "fe80::%eth0" {
+		t.Errorf("%v should equal fe80::%%eth0", f.String())
+	}
+	if l := z.LastAddr(); l.String() != "fe80::ffff:ffff:ffff:ffff%eth0" {
+		t.Errorf("%v should equal fe80::ffff:ffff:ffff:ffff%%eth0", l.String())
+	}
+	if n := z.NetworkID(); n.String() != "fe80::%eth0/64" {
+		t.Errorf("%v should equal fe80::%%eth0/64", n.String())
+	}
+}
+
+// nolint dupl
+func TestZonedContains(t *testing.T) {
+	z, _ := ParseZonedCIDR("fe80::1%eth0/64")
+	a, _ := ParseZonedIP("fe80::2%eth0")
+	b, _ := ParseZonedIP("fe80::2%eth1")
+	if !z.Contains(a) {
+		t.Errorf("%v should contain %v", z.String(), a.String())
+	}
+	if z.Contains(b) {
+		t.Errorf("%v should not contain %v", z.String(), b.String())
+	}
+}
+
+// nolint dupl
+func TestZonedIPAdd(t *testing.T) {
+	a, _ := ParseZonedIP("fe80::ffff%eth0")
+	r := ZonedIPAdd(a, 1)
+	if r.String() != "fe80::1:0%eth0" {
+		t.Errorf("%v should equal fe80::1:0%%eth0", r.String())
+	}
+	if r := ZonedIPAdd(nil, 1); r != nil {
+		t.Errorf("expected nil, got %v", r)
+	}
+}
+
+// nolint dupl
+func TestZonedIPCompare(t *testing.T) {
+	a, _ := ParseZonedIP("fe80::1%eth0")
+	b, _ := ParseZonedIP("fe80::1%eth1")
+	if ZonedIPEqual(a, b) {
+		t.Errorf("%v should not equal %v", a.String(), b.String())
+	}
+	if !ZonedIPBefore(a, b) {
+		t.Errorf("%v should be before %v", a.String(), b.String())
+	}
+	c, _ := ParseZonedIP("fe80::2%eth0")
+	if !ZonedIPBefore(b, c) {
+		t.Errorf("%v should be before %v", b.String(), c.String())
+	}
+	if !ZonedIPBefore(nil, a) || ZonedIPBefore(a, nil) || ZonedIPBefore(nil, nil) {
+		t.Errorf("nil should be before any address")
+	}
+}
+
+// nolint dupl
+func TestZoneIndex(t *testing.T) {
+	if i, err := ZoneIndex("7"); err != nil || i != 7 {
+		t.Errorf("numeric zone should return 7, got %v, %v", i, err)
+	}
+	lo, err := net.InterfaceByIndex(1)
+	if err != nil {
+		t.Skip("no loopback interface")
+	}
+	if i, err := ZoneIndex(lo.Name); err != nil || i != lo.Index {
+		t.Errorf("zone %v should have index %v, got %v, %v", lo.Name, lo.Index, i, err)
+	}
+	if n, err := ZoneName(lo.Index); err != nil || n != lo.Name {
+		t.Errorf("index %v should have name %v, got %v, %v", lo.Index, lo.Name, n, err)
+	}
+	if _, err := ZoneIndex("../nonexistent"); err == nil {
+		t.Errorf("bad zone should return an error")
+	}
+}